@@ -0,0 +1,548 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	applyScope  string
+	applyRevert bool
+)
+
+// apply 子命令
+var applyCmd = &cobra.Command{
+	Use:   "apply [name]",
+	Short: "将平台环境变量写入 Claude Code 的 settings 文件",
+	Long: `将平台的 ANTHROPIC_* 环境变量合并到 Claude Code settings 文件的 env 配置中，
+使 IDE 插件等不经过 ccgate 启动的 claude 也使用该平台。
+
+  --scope user     ~/.claude/settings.json（默认）
+  --scope project  ./.claude/settings.json（通常会提交到仓库）
+  --scope local    ./.claude/settings.local.json
+
+settings 文件中的其他配置保持不变。ccgate 会记录被替换的原值，
+使用 --revert 可以精确恢复。
+
+示例:
+  ccgate apply prod
+  ccgate apply staging --scope local
+  ccgate apply --revert --scope local`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if applyRevert {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := claudeSettingsPathForScope(applyScope)
+		if err != nil {
+			return err
+		}
+
+		if applyRevert {
+			return revertSettings(path)
+		}
+
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+		platform, err := findPlatformByName(config.Platforms, args[0])
+		if err != nil {
+			return unknownPlatformError(config.Platforms, args[0], err)
+		}
+
+		// project 范围的文件通常会提交到仓库，写入令牌前需要确认
+		if applyScope == "project" && !skipConfirm {
+			theme := DefaultTheme()
+			DisplayWarning(fmt.Sprintf("%s 通常会提交到仓库，写入后令牌可能泄露", path), theme)
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				return NewUserError("当前环境无法确认写入", "建议使用 --scope local；确认无误时使用 --yes 跳过确认")
+			}
+			ok, err := pterm.DefaultInteractiveConfirm.
+				WithDefaultText("仍然写入?").
+				Show()
+			if err != nil || !ok {
+				DisplayWarning("已取消", theme)
+				return nil
+			}
+		}
+
+		return applyToSettings(path, platform)
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringVar(&applyScope, "scope", "user", "写入范围（user|project|local）")
+	applyCmd.Flags().BoolVar(&applyRevert, "revert", false, "恢复上一次 apply 之前的值")
+	applyCmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "跳过确认提示")
+}
+
+// claudeSettingsPathForScope 返回指定范围的 Claude Code settings 文件路径
+func claudeSettingsPathForScope(scope string) (string, error) {
+	switch scope {
+	case "user":
+		return claudeSettingsPath(), nil
+	case "project", "local":
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("获取当前目录失败: %w", err)
+		}
+		file := "settings.json"
+		if scope == "local" {
+			file = "settings.local.json"
+		}
+		return filepath.Join(cwd, ".claude", file), nil
+	default:
+		return "", NewUserError(
+			fmt.Sprintf("不支持的范围: %s", scope),
+			"--scope 可选值为 user、project、local")
+	}
+}
+
+// applyRecord 记录一次 apply 替换前的值，用于 revert
+type applyRecord struct {
+	Platform  string    `json:"platform"`
+	AppliedAt time.Time `json:"applied_at"`
+	// Created、CreatedDir apply 前 settings 文件及其所在目录不存在，revert 时删除
+	Created    bool     `json:"created,omitempty"`
+	CreatedDir bool     `json:"created_dir,omitempty"`
+	HadEnv     bool     `json:"had_env"`
+	EnvOrder   []string `json:"env_order,omitempty"` // 原 env 的键顺序
+	// Previous 被替换变量的原值（原样保存的 JSON，不限于字符串），Missing 原先不存在的变量
+	Previous map[string]json.RawMessage `json:"previous,omitempty"`
+	Missing  []string                   `json:"missing,omitempty"`
+}
+
+// recorded 判断是否已记录变量的原值（或原先不存在）
+func (r *applyRecord) recorded(key string) bool {
+	if _, ok := r.Previous[key]; ok {
+		return true
+	}
+	return slices.Contains(r.Missing, key)
+}
+
+// applyStatePath 返回 apply 记录文件路径，与当前使用的配置文件（-f/--config）位于同一目录
+func applyStatePath() string {
+	configPath := cfgFile
+	if configPath == "" {
+		configPath = getConfigPath()
+	}
+	return filepath.Join(filepath.Dir(configPath), "apply-state.json")
+}
+
+// loadApplyState 加载所有 settings 文件的 apply 记录，键为 settings 文件路径
+func loadApplyState() (map[string]*applyRecord, error) {
+	state := make(map[string]*applyRecord)
+	data, err := os.ReadFile(applyStatePath())
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 apply 记录失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("apply 记录格式无效: %w", err)
+	}
+	return state, nil
+}
+
+// saveApplyState 保存 apply 记录（包含原有令牌，仅当前用户可读）
+func saveApplyState(state map[string]*applyRecord) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 apply 记录失败: %w", err)
+	}
+	if err := os.WriteFile(applyStatePath(), data, 0o600); err != nil {
+		return fmt.Errorf("写入 apply 记录失败: %w", err)
+	}
+	return nil
+}
+
+// applyToSettings 将平台环境变量合并到 settings 文件
+func applyToSettings(path string, platform *Platform) error {
+	_, dirErr := os.Stat(filepath.Dir(path))
+	settings, layout, err := readSettingsFile(path)
+	if err != nil {
+		return err
+	}
+	env, hadEnv, err := settings.object("env")
+	if err != nil {
+		return fmt.Errorf("%s 中的 env 格式无效: %w", path, err)
+	}
+
+	state, err := loadApplyState()
+	if err != nil {
+		return err
+	}
+
+	// 多次 apply 时保留最初的原值，保证 revert 恢复到第一次 apply 之前
+	record, ok := state[path]
+	if !ok {
+		record = &applyRecord{
+			Created:    !layout.Exists,
+			CreatedDir: os.IsNotExist(dirErr),
+			HadEnv:     hadEnv,
+			EnvOrder:   append([]string(nil), env.keys...),
+			Previous:   make(map[string]json.RawMessage),
+		}
+		state[path] = record
+	}
+	if record.Previous == nil {
+		record.Previous = make(map[string]json.RawMessage)
+	}
+	record.Platform = platform.Name
+	record.AppliedAt = time.Now()
+
+	if uiErr := ensurePlatformToken(platform); uiErr != nil {
+		return uiErr
+	}
+	if platform.TokenCommand != "" {
+		DisplayWarning("该平台的令牌由 token_command 生成，写入 settings 的令牌过期后需要重新 apply", DefaultTheme())
+	}
+
+	vars := platformEnvMap(platform)
+	// 平台未设置快速模型时移除旧值，避免混用其他平台的模型
+	if _, ok := vars["ANTHROPIC_SMALL_FAST_MODEL"]; !ok {
+		vars["ANTHROPIC_SMALL_FAST_MODEL"] = ""
+	}
+	// 同理移除另一种令牌变量，避免 ANTHROPIC_API_KEY 与 ANTHROPIC_AUTH_TOKEN 同时生效
+	for _, key := range []string{"ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_API_KEY"} {
+		if _, ok := vars[key]; !ok {
+			vars[key] = ""
+		}
+	}
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !record.recorded(key) {
+			if raw, ok := env.values[key]; ok {
+				record.Previous[key] = raw
+			} else {
+				record.Missing = append(record.Missing, key)
+			}
+		}
+		if vars[key] == "" {
+			env.delete(key)
+		} else {
+			env.setString(key, vars[key])
+		}
+	}
+	if err := settings.setObject("env", env); err != nil {
+		return err
+	}
+
+	if err := writeSettingsFile(path, settings, layout); err != nil {
+		return err
+	}
+	if err := saveApplyState(state); err != nil {
+		return err
+	}
+
+	DisplaySuccess(fmt.Sprintf("已将平台 '%s' 写入 %s", platform.Name, path), DefaultTheme())
+	return nil
+}
+
+// revertSettings 恢复 settings 文件中被 apply 替换的值
+func revertSettings(path string) error {
+	theme := DefaultTheme()
+
+	state, err := loadApplyState()
+	if err != nil {
+		return err
+	}
+	record, ok := state[path]
+	if !ok {
+		DisplayWarning(fmt.Sprintf("%s 没有可恢复的 apply 记录", path), theme)
+		return nil
+	}
+
+	settings, layout, err := readSettingsFile(path)
+	if err != nil {
+		return err
+	}
+	env, _, err := settings.object("env")
+	if err != nil {
+		return fmt.Errorf("%s 中的 env 格式无效: %w", path, err)
+	}
+
+	for _, key := range record.Missing {
+		env.delete(key)
+	}
+	for key, previous := range record.Previous {
+		env.set(key, previous)
+	}
+
+	env.reorder(record.EnvOrder)
+
+	if len(env.keys) == 0 && !record.HadEnv {
+		settings.delete("env")
+	} else if err := settings.setObject("env", env); err != nil {
+		return err
+	}
+
+	// apply 创建的文件在恢复后没有其他内容时删除，连同 apply 创建的目录
+	if record.Created && len(settings.keys) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除 %s 失败: %w", path, err)
+		}
+		if record.CreatedDir {
+			_ = os.Remove(filepath.Dir(path))
+		}
+	} else if err := writeSettingsFile(path, settings, layout); err != nil {
+		return err
+	}
+	delete(state, path)
+	if err := saveApplyState(state); err != nil {
+		return err
+	}
+
+	DisplaySuccess(fmt.Sprintf("已恢复 %s（撤销平台 '%s'）", path, record.Platform), theme)
+	return nil
+}
+
+// settingsLayout settings 文件原有的格式，写回时保持一致，使 revert 能逐字节恢复
+type settingsLayout struct {
+	Exists bool
+	// Indent 每级缩进，为空时输出单行的紧凑格式
+	Indent  string
+	Newline bool
+}
+
+// defaultSettingsLayout 新建 settings 文件时使用的格式
+var defaultSettingsLayout = settingsLayout{Indent: "  ", Newline: true}
+
+// detectSettingsLayout 根据第一个缩进的行判断文件的缩进方式
+func detectSettingsLayout(data []byte) settingsLayout {
+	layout := settingsLayout{Exists: true, Newline: bytes.HasSuffix(data, []byte("\n"))}
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	if len(lines) < 2 {
+		return layout
+	}
+	for _, line := range lines[1:] {
+		if indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]; len(indent) > 0 {
+			layout.Indent = string(indent)
+			return layout
+		}
+	}
+	layout.Indent = defaultSettingsLayout.Indent
+	return layout
+}
+
+// readSettingsFile 读取 settings 文件及其格式，不存在时返回空对象
+func readSettingsFile(path string) (*orderedObject, settingsLayout, error) {
+	obj := &orderedObject{values: make(map[string]json.RawMessage)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return obj, defaultSettingsLayout, nil
+	}
+	if err != nil {
+		return nil, settingsLayout{}, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		layout := defaultSettingsLayout
+		layout.Exists = true
+		return obj, layout, nil
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, settingsLayout{}, fmt.Errorf("%s 不是有效的 JSON 对象: %w", path, err)
+	}
+	return obj, detectSettingsLayout(data), nil
+}
+
+// writeSettingsFile 按原有格式写入 settings 文件，保持原有权限（新文件为 0600）
+func writeSettingsFile(path string, obj *orderedObject, layout settingsLayout) error {
+	data, err := marshalJSON(obj)
+	if err != nil {
+		return fmt.Errorf("序列化 %s 失败: %w", path, err)
+	}
+	var out bytes.Buffer
+	if layout.Indent == "" {
+		out.Write(data)
+	} else if err := json.Indent(&out, data, "", layout.Indent); err != nil {
+		return fmt.Errorf("格式化 %s 失败: %w", path, err)
+	}
+	if layout.Newline {
+		out.WriteByte('\n')
+	}
+
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建目录 %s 失败: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), mode); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// marshalJSON 与 json.Marshal 相同，但不转义 &、<、>，
+// 否则 "Bash(a && b)" 之类的权限规则写回后会变成 \u0026，revert 也无法恢复原文件
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// orderedObject 保持键顺序的 JSON 对象，用于修改 settings 文件时不打乱其他配置
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// UnmarshalJSON 按原始顺序解析对象的键
+func (o *orderedObject) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("期望 JSON 对象")
+	}
+
+	o.keys = nil
+	o.values = make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if _, exists := o.values[key]; !exists {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = value
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// MarshalJSON 按记录的顺序输出对象
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := marshalJSON(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// object 返回嵌套对象，不存在时返回空对象和 false
+func (o *orderedObject) object(key string) (*orderedObject, bool, error) {
+	child := &orderedObject{values: make(map[string]json.RawMessage)}
+	raw, ok := o.values[key]
+	if !ok {
+		return child, false, nil
+	}
+	if err := json.Unmarshal(raw, child); err != nil {
+		return nil, true, err
+	}
+	return child, true, nil
+}
+
+// setObject 设置嵌套对象
+func (o *orderedObject) setObject(key string, child *orderedObject) error {
+	raw, err := marshalJSON(child)
+	if err != nil {
+		return err
+	}
+	o.set(key, raw)
+	return nil
+}
+
+// stringValue 返回字符串值，不存在或不是字符串时返回 nil
+func (o *orderedObject) stringValue(key string) *string {
+	raw, ok := o.values[key]
+	if !ok {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil
+	}
+	return &s
+}
+
+// setString 设置字符串值
+func (o *orderedObject) setString(key, value string) {
+	raw, _ := marshalJSON(value)
+	o.set(key, raw)
+}
+
+// set 设置原始值，新键追加到末尾
+func (o *orderedObject) set(key string, raw json.RawMessage) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+}
+
+// reorder 按给定顺序排列已有的键，其余键保持相对顺序排在后面
+func (o *orderedObject) reorder(order []string) {
+	keys := make([]string, 0, len(o.keys))
+	placed := make(map[string]bool)
+	for _, key := range order {
+		if _, ok := o.values[key]; ok && !placed[key] {
+			keys = append(keys, key)
+			placed[key] = true
+		}
+	}
+	for _, key := range o.keys {
+		if !placed[key] {
+			keys = append(keys, key)
+		}
+	}
+	o.keys = keys
+}
+
+// delete 删除键
+func (o *orderedObject) delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestApplyAndRevertSettings tests that apply merges env and revert restores the file exactly
+func TestApplyAndRevertSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := filepath.Join(home, ".claude", "settings.json")
+	original := `{
+  "permissions": {
+    "allow": [
+      "Bash(ls:*)",
+      "Bash(make build && make test)",
+      "Read(<repo>/docs/**)"
+    ]
+  },
+  "env": {
+    "ANTHROPIC_MODEL": "old-model",
+    "ANTHROPIC_SMALL_FAST_MODEL": "old-small",
+    "OTHER": "1"
+  },
+  "model": "opus"
+}
+`
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	platform := &Platform{
+		Name:               "test",
+		AnthropicBaseURL:   "https://api.test.com",
+		AnthropicAuthToken: "test-token",
+		AnthropicModel:     "new-model",
+	}
+	if err := applyToSettings(path, platform); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	settings, _, err := readSettingsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, _, _ := settings.object("env")
+	if v := env.stringValue("ANTHROPIC_MODEL"); v == nil || *v != "new-model" {
+		t.Errorf("Expected ANTHROPIC_MODEL=new-model, got %v", v)
+	}
+	if v := env.stringValue("ANTHROPIC_SMALL_FAST_MODEL"); v != nil {
+		t.Errorf("Expected ANTHROPIC_SMALL_FAST_MODEL to be removed, got %s", *v)
+	}
+	if v := env.stringValue("OTHER"); v == nil || *v != "1" {
+		t.Error("Expected unrelated env key to be preserved")
+	}
+	if _, ok := settings.values["permissions"]; !ok {
+		t.Error("Expected unrelated top-level key to be preserved")
+	}
+
+	// 再次 apply 后 revert 仍应恢复到第一次 apply 之前
+	platform.AnthropicSmallModel = "new-small"
+	if err := applyToSettings(path, platform); err != nil {
+		t.Fatal(err)
+	}
+	if err := revertSettings(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != original {
+		t.Errorf("Expected file to be restored exactly, got:\n%s", data)
+	}
+}
+
+// TestRevertSettingsLayout tests byte-exact revert for other indentation and non-string values
+func TestRevertSettingsLayout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	platform := &Platform{Name: "test", AnthropicBaseURL: "https://api.test.com", AnthropicAuthToken: "test-token", AnthropicModel: "new-model"}
+
+	for name, original := range map[string]string{
+		"four spaces": "{\n    \"env\": {\n        \"ANTHROPIC_MODEL\": 42,\n        \"ANTHROPIC_API_KEY\": true\n    }\n}\n",
+		"tabs":        "{\n\t\"env\": {\n\t\t\"ANTHROPIC_SMALL_FAST_MODEL\": null\n\t},\n\t\"model\": \"opus\"\n}",
+		"compact":     `{"env":{"ANTHROPIC_MODEL":["a","b"]},"model":"opus"}`,
+	} {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyToSettings(path, platform); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if err := revertSettings(path); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != original {
+			t.Errorf("%s: expected file to be restored exactly, got:\n%s", name, data)
+		}
+	}
+}
+
+// TestApplyStatePath tests that the apply state lives next to the config given with -f
+func TestApplyStatePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if got, want := applyStatePath(), filepath.Join(home, ".ccgate", "apply-state.json"); got != want {
+		t.Errorf("Expected default state %s, got %s", want, got)
+	}
+
+	dir := t.TempDir()
+	old := cfgFile
+	cfgFile = filepath.Join(dir, "work.json")
+	defer func() { cfgFile = old }()
+
+	path := filepath.Join(t.TempDir(), "settings.json")
+	platform := &Platform{Name: "test", AnthropicBaseURL: "https://api.test.com", AnthropicAuthToken: "test-token", AnthropicModel: "m"}
+	if err := applyToSettings(path, platform); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "apply-state.json")); err != nil {
+		t.Errorf("Expected apply state next to the config, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".ccgate", "apply-state.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected default apply state to be untouched, got %v", err)
+	}
+}
+
+// TestRevertSettingsCreatedFile tests that revert removes a settings file and directory created by apply
+func TestRevertSettingsCreatedFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := filepath.Join(t.TempDir(), ".claude")
+	path := filepath.Join(dir, "settings.local.json")
+	platform := &Platform{Name: "test", AnthropicBaseURL: "https://api.test.com", AnthropicAuthToken: "test-token", AnthropicModel: "m"}
+
+	if err := applyToSettings(path, platform); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := revertSettings(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", dir, err)
+	}
+
+	// apply 之后用户又添加了其他设置时保留文件
+	if err := applyToSettings(path, platform); err != nil {
+		t.Fatal(err)
+	}
+	settings, layout, _ := readSettingsFile(path)
+	settings.setString("model", "opus")
+	if err := writeSettingsFile(path, settings, layout); err != nil {
+		t.Fatal(err)
+	}
+	if err := revertSettings(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "{\n  \"model\": \"opus\"\n}\n" {
+		t.Errorf("Expected only the user's setting to remain, got %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// 根命令参数模式
+const (
+	// flagModeEnv 设置为 namespaced 时根命令只识别 --cc-* 参数，其余参数全部传递给 claude
+	flagModeEnv        = "CCGATE_FLAG_MODE"
+	flagModeNamespaced = "namespaced"
+
+	// namespacedFlagPrefix 不会与 claude 冲突的 ccgate 参数前缀，如 --cc-platform
+	namespacedFlagPrefix = "cc-"
+)
+
+// namespacedFlagNames 根命令中属于 ccgate 的参数，每个都有对应的 --cc-* 别名
+// 未列出的根命令参数（如仅用于补全的 --model）原样传递给 claude
+var namespacedFlagNames = []string{"platform", "yes", "config", "output", "dry-run", "supervise", "record"}
+
+// claudeFlag claude 的命令行参数
+type claudeFlag struct {
+	Name      string
+	Shorthand string
+	// TakesValue 参数需要一个值（下一个参数始终作为值传递，不会被 ccgate 解析）
+	TakesValue bool
+}
+
+// claudeFlags claude 的命令行参数（claude --help）
+// 可选值的参数（--resume、--debug）按布尔参数处理，其值作为普通参数原样传递
+var claudeFlags = []claudeFlag{
+	{Name: "print", Shorthand: "p"},
+	{Name: "continue", Shorthand: "c"},
+	{Name: "resume", Shorthand: "r"},
+	{Name: "debug", Shorthand: "d"},
+	{Name: "version", Shorthand: "v"},
+	{Name: "help", Shorthand: "h"},
+	{Name: "verbose"},
+	{Name: "ide"},
+	{Name: "mcp-debug"},
+	{Name: "strict-mcp-config"},
+	{Name: "dangerously-skip-permissions"},
+	{Name: "include-partial-messages"},
+	{Name: "replay-user-messages"},
+	{Name: "fork-session"},
+	{Name: "model", TakesValue: true},
+	{Name: "fallback-model", TakesValue: true},
+	{Name: "output-format", TakesValue: true},
+	{Name: "input-format", TakesValue: true},
+	{Name: "system-prompt", TakesValue: true},
+	{Name: "append-system-prompt", TakesValue: true},
+	{Name: "permission-mode", TakesValue: true},
+	{Name: "permission-prompt-tool", TakesValue: true},
+	{Name: "allowedTools", TakesValue: true},
+	{Name: "allowed-tools", TakesValue: true},
+	{Name: "disallowedTools", TakesValue: true},
+	{Name: "disallowed-tools", TakesValue: true},
+	{Name: "add-dir", TakesValue: true},
+	{Name: "mcp-config", TakesValue: true},
+	{Name: "settings", TakesValue: true},
+	{Name: "setting-sources", TakesValue: true},
+	{Name: "session-id", TakesValue: true},
+	{Name: "agents", TakesValue: true},
+	{Name: "max-turns", TakesValue: true},
+}
+
+// claudeTakesValue 判断 claude 的长参数是否需要值
+func claudeTakesValue(name string) bool {
+	for _, f := range claudeFlags {
+		if f.Name == name {
+			return f.TakesValue
+		}
+	}
+	return false
+}
+
+// claudeHasShorthand 判断短参数是否同时是 claude 的参数
+func claudeHasShorthand(shorthand string) bool {
+	for _, f := range claudeFlags {
+		if f.Shorthand == shorthand {
+			return true
+		}
+	}
+	return false
+}
+
+// registerNamespacedFlags 为根命令的 ccgate 参数注册 --cc-* 别名，别名与原参数共享同一个值
+func registerNamespacedFlags(cmd *cobra.Command) {
+	for _, name := range namespacedFlagNames {
+		f := cmd.Flag(name)
+		alias := cmd.Flags().VarPF(f.Value, namespacedFlagPrefix+name, "", f.Usage+"（不会与 claude 的参数冲突）")
+		alias.NoOptDefVal = f.NoOptDefVal
+	}
+}
+
+// isCompletionRequest 判断是否为 shell 补全请求
+func isCompletionRequest(args []string) bool {
+	return len(args) > 0 && (args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd)
+}
+
+// namespacedFlagMode 判断是否只识别 --cc-* 参数
+func namespacedFlagMode() bool {
+	return os.Getenv(flagModeEnv) == flagModeNamespaced
+}
+
+// rootArgs 根命令参数的拆分结果
+type rootArgs struct {
+	// Claude 传递给 claude 的参数，保持原有顺序
+	Claude []string
+	// Help 请求显示 ccgate 的帮助
+	Help bool
+	// ShortPlatform 平台通过 -p 指定，可能是 claude 的 print 模式被误用
+	ShortPlatform bool
+	// Warnings 有歧义的输入，参数已按说明的方式处理
+	Warnings []string
+}
+
+// rootArgParser 拆分根命令参数：ccgate 参数写入 cobra 注册的 flag，其余参数传递给 claude
+//
+// 规则：
+//   - "--" 之后的参数全部原样传递给 claude
+//   - --cc-* 始终是 ccgate 参数；namespaced 模式下只识别 --cc-*
+//   - 其他模式下 -p/--platform、-y/--yes、-f/--config、--output、--dry-run、--supervise、--record 也是 ccgate 参数
+//   - 组合短参数只有全部由 ccgate 短参数组成时才由 ccgate 解析，否则整体传递给 claude
+//   - 与 claude 冲突的短参数（-p）在已指定平台或缺少值时作为 claude 参数传递
+type rootArgParser struct {
+	cmd        *cobra.Command
+	namespaced bool
+	result     rootArgs
+}
+
+// parseRootArgs 拆分根命令参数
+func parseRootArgs(cmd *cobra.Command, args []string, namespaced bool) (rootArgs, error) {
+	p := &rootArgParser{cmd: cmd, namespaced: namespaced}
+	if err := p.parse(args); err != nil {
+		return rootArgs{}, err
+	}
+	return p.result, nil
+}
+
+// ccgateFlag 返回名称对应的 ccgate 参数，不属于 ccgate 时返回 nil
+func (p *rootArgParser) ccgateFlag(name string) *pflag.Flag {
+	if strings.HasPrefix(name, namespacedFlagPrefix) {
+		return p.cmd.Flag(name)
+	}
+	if p.namespaced || p.cmd.Flag(namespacedFlagPrefix+name) == nil {
+		return nil
+	}
+	return p.cmd.Flag(name)
+}
+
+// ccgateShorthand 返回短参数对应的 ccgate 参数
+func (p *rootArgParser) ccgateShorthand(c byte) *pflag.Flag {
+	for _, name := range namespacedFlagNames {
+		if f := p.cmd.Flag(name); f != nil && f.Shorthand == string(c) {
+			return f
+		}
+	}
+	return nil
+}
+
+// set 设置 ccgate 参数的值
+func (p *rootArgParser) set(f *pflag.Flag, value string, display string) error {
+	if err := f.Value.Set(value); err != nil {
+		return NewValidationError(
+			fmt.Sprintf("参数 %s 的值无效: %s", display, value),
+			"运行 'ccgate --help' 查看参数说明")
+	}
+	f.Changed = true
+	return nil
+}
+
+// platformChanged 判断是否已经指定了平台
+func (p *rootArgParser) platformChanged() bool {
+	for _, name := range []string{"platform", namespacedFlagPrefix + "platform"} {
+		if f := p.cmd.Flag(name); f != nil && f.Changed {
+			return true
+		}
+	}
+	return false
+}
+
+// warn 记录有歧义的输入
+func (p *rootArgParser) warn(format string, args ...any) {
+	p.result.Warnings = append(p.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (p *rootArgParser) parse(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var consumed int
+		var err error
+
+		switch {
+		case arg == "--":
+			p.result.Claude = append(p.result.Claude, args[i+1:]...)
+			return nil
+		case strings.HasPrefix(arg, "--"):
+			consumed, err = p.parseLong(arg, args[i+1:])
+		case len(arg) > 1 && arg[0] == '-' && !p.namespaced:
+			consumed, err = p.parseShort(arg, args[i+1:])
+		default:
+			p.result.Claude = append(p.result.Claude, arg)
+		}
+		if err != nil {
+			return err
+		}
+		i += consumed
+	}
+	return nil
+}
+
+// parseLong 处理长参数，返回额外消耗的参数个数
+func (p *rootArgParser) parseLong(arg string, rest []string) (int, error) {
+	name, value, hasValue := strings.Cut(arg[2:], "=")
+
+	if name == "help" && !p.namespaced {
+		p.result.Help = true
+		return 0, nil
+	}
+
+	f := p.ccgateFlag(name)
+	if f == nil {
+		if strings.HasPrefix(name, namespacedFlagPrefix) {
+			return 0, NewValidationError(
+				fmt.Sprintf("未知的 ccgate 参数: --%s", name),
+				"可用的 --cc-* 参数: --cc-"+strings.Join(namespacedFlagNames, ", --cc-"))
+		}
+		// claude 的参数：需要值时连同下一个参数一起传递，避免值被当作 ccgate 参数
+		p.result.Claude = append(p.result.Claude, arg)
+		if !hasValue && claudeTakesValue(name) && len(rest) > 0 {
+			p.result.Claude = append(p.result.Claude, rest[0])
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	switch {
+	case hasValue:
+		return 0, p.set(f, value, "--"+name)
+	case f.NoOptDefVal != "":
+		return 0, p.set(f, f.NoOptDefVal, "--"+name)
+	case len(rest) == 0:
+		return 0, NewValidationError(
+			fmt.Sprintf("参数 --%s 需要一个值", name),
+			fmt.Sprintf("示例: ccgate --%s <值>", name))
+	}
+	return 1, p.set(f, rest[0], "--"+name)
+}
+
+// parseShort 处理短参数及组合短参数（如 -yp prod），返回额外消耗的参数个数
+func (p *rootArgParser) parseShort(arg string, rest []string) (int, error) {
+	letters := arg[1:]
+
+	if !p.ownsShortGroup(letters) {
+		for i := 0; i < len(letters); i++ {
+			if f := p.ccgateShorthand(letters[i]); f != nil && len(letters) > 1 {
+				p.warn("组合参数 %s 原样传递给 claude，其中的 -%c 不会作为 ccgate 的 --%s；需要时请单独书写或使用 --cc-%s",
+					arg, letters[i], f.Name, f.Name)
+				break
+			}
+		}
+		p.result.Claude = append(p.result.Claude, arg)
+		return 0, nil
+	}
+
+	for i := 0; i < len(letters); i++ {
+		c := letters[i]
+		if c == 'h' {
+			p.result.Help = true
+			continue
+		}
+		f := p.ccgateShorthand(c)
+		if f.NoOptDefVal != "" {
+			if err := p.set(f, f.NoOptDefVal, "-"+string(c)); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		// 需要值的短参数：组合中剩余的部分即为值（-pprod、-p=prod），否则使用下一个参数
+		if attached := strings.TrimPrefix(letters[i+1:], "="); attached != "" {
+			if p.allShorthands(attached) {
+				p.warn("%s 被解析为 -%c %s；如果本意是多个参数，请分开书写", arg, c, attached)
+			}
+			if err := p.set(f, attached, "-"+string(c)); err != nil {
+				return 0, err
+			}
+			p.markShortPlatform(f)
+			return 0, nil
+		}
+
+		if len(rest) == 0 || (claudeHasShorthand(string(c)) && strings.HasPrefix(rest[0], "-")) {
+			if claudeHasShorthand(string(c)) {
+				p.warn("-%c 后没有 %s 的值，按 claude 的 -%c 参数传递", c, f.Name, c)
+				p.result.Claude = append(p.result.Claude, "-"+string(c))
+				return 0, nil
+			}
+			return 0, NewValidationError(
+				fmt.Sprintf("参数 -%c 需要一个值", c),
+				fmt.Sprintf("示例: ccgate -%c <值>", c))
+		}
+		if err := p.set(f, rest[0], "-"+string(c)); err != nil {
+			return 0, err
+		}
+		p.markShortPlatform(f)
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// ownsShortGroup 判断组合短参数是否由 ccgate 解析
+// 所有字母（直到第一个需要值的参数）都必须是 ccgate 的短参数；
+// 与 claude 冲突的短参数在已指定平台后归 claude 所有
+func (p *rootArgParser) ownsShortGroup(letters string) bool {
+	for i := 0; i < len(letters); i++ {
+		c := letters[i]
+		if c == 'h' {
+			continue
+		}
+		f := p.ccgateShorthand(c)
+		if f == nil {
+			return false
+		}
+		if f.NoOptDefVal != "" {
+			continue
+		}
+		if f.Name == "platform" && p.platformChanged() {
+			if len(letters) == 1 && p.result.ShortPlatform {
+				p.warn("已经指定了平台，第二个 -p 按 claude 的 print 参数传递；建议使用 --cc-platform 或 -- 消除歧义")
+			}
+			return false
+		}
+		return true
+	}
+	return true
+}
+
+// allShorthands 判断字符串是否全部由 ccgate 的短参数字母组成（如 -fy 中的 y）
+func (p *rootArgParser) allShorthands(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if p.ccgateShorthand(s[i]) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// markShortPlatform 记录平台是否通过 -p 指定
+func (p *rootArgParser) markShortPlatform(f *pflag.Flag) {
+	if f.Name == "platform" {
+		p.result.ShortPlatform = true
+	}
+}
+
+// displayArgWarnings 显示参数歧义警告，结构化输出时写到标准错误
+func displayArgWarnings(warnings []string) {
+	for _, w := range warnings {
+		if structuredOutput() {
+			fmt.Fprintf(os.Stderr, "警告: %s\n", w)
+			continue
+		}
+		DisplayWarning(w, DefaultTheme())
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// resetRootFlags 将根命令的 ccgate 参数恢复为默认值
+func resetRootFlags(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		for _, name := range namespacedFlagNames {
+			for _, n := range []string{name, namespacedFlagPrefix + name} {
+				f := rootCmd.Flag(n)
+				if err := f.Value.Set(f.DefValue); err != nil {
+					t.Fatalf("reset %s: %v", n, err)
+				}
+				f.Changed = false
+			}
+		}
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// TestParseRootArgs tests splitting ccgate flags from claude arguments
+func TestParseRootArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		namespaced bool
+
+		claude   []string
+		platform string
+		yes      bool
+		config   string
+		output   string
+		dryRun   bool
+		help     bool
+		short    bool
+		// warn 期望的警告片段，为空时不应有警告
+		warn string
+	}{
+		{name: "no args"},
+		{name: "platform and claude flag", args: []string{"-p", "prod", "--continue"}, platform: "prod", short: true, claude: []string{"--continue"}},
+		{name: "long platform with equals", args: []string{"--platform=prod", "-c"}, platform: "prod", claude: []string{"-c"}},
+		{name: "attached short value", args: []string{"-pprod"}, platform: "prod", short: true},
+		{name: "short value with equals", args: []string{"-p=prod"}, platform: "prod", short: true},
+		{name: "combined bool and value", args: []string{"-yp", "prod"}, platform: "prod", yes: true, short: true},
+		{name: "all ccgate flags", args: []string{"-f", "c.json", "--output", "json", "--dry-run", "--supervise", "-y", "--platform", "prod"},
+			config: "c.json", output: "json", dryRun: true, yes: true, platform: "prod"},
+		{name: "output equals", args: []string{"--output=yaml", "--output-format", "json"}, output: "yaml", claude: []string{"--output-format", "json"}},
+		{name: "claude model is passed through", args: []string{"--model", "opus", "-p", "prod"}, platform: "prod", short: true, claude: []string{"--model", "opus"}},
+		{name: "help", args: []string{"-h"}, help: true},
+		{name: "long help", args: []string{"--help"}, help: true},
+
+		// -- 是硬分隔符
+		{name: "separator", args: []string{"-p", "prod", "--", "-p", "summarize", "-y", "--platform", "x"}, platform: "prod", short: true,
+			claude: []string{"-p", "summarize", "-y", "--platform", "x"}},
+		{name: "separator first", args: []string{"--", "--help"}, claude: []string{"--help"}},
+		{name: "separator not passed", args: []string{"--"}},
+
+		// 与 claude 的 -p（print）的歧义
+		{name: "second -p is print", args: []string{"-p", "prod", "-p", "summarize"}, platform: "prod", short: true,
+			claude: []string{"-p", "summarize"}, warn: "第二个 -p"},
+		{name: "-p after long platform is print", args: []string{"--cc-platform", "prod", "-p", "summarize"}, platform: "prod",
+			claude: []string{"-p", "summarize"}},
+		{name: "-p without value", args: []string{"-p"}, claude: []string{"-p"}, warn: "-p 后没有"},
+		{name: "-p followed by flag", args: []string{"-p", "--verbose"}, claude: []string{"-p", "--verbose"}, warn: "-p 后没有"},
+		{name: "claude combined flags", args: []string{"-cp", "hi"}, claude: []string{"-cp", "hi"}, warn: "组合参数 -cp"},
+		{name: "claude combined flags without ccgate letters", args: []string{"-cd"}, claude: []string{"-cd"}},
+		{name: "config swallowing letters", args: []string{"-fy"}, config: "y", warn: "-fy 被解析为 -f y"},
+		{name: "combined after platform", args: []string{"-p", "prod", "-yp"}, platform: "prod", short: true,
+			claude: []string{"-yp"}, warn: "组合参数 -yp"},
+
+		// 命名空间参数
+		{name: "namespaced flags", args: []string{"--cc-platform", "prod", "--cc-yes", "--cc-config=c.json", "--cc-dry-run"},
+			platform: "prod", yes: true, config: "c.json", dryRun: true},
+		{name: "namespaced mode passes short flags", namespaced: true,
+			args:   []string{"--cc-platform", "prod", "-p", "hi", "-y", "-f", "--platform", "x", "--help"},
+			claude: []string{"-p", "hi", "-y", "-f", "--platform", "x", "--help"}, platform: "prod"},
+		{name: "namespaced mode with separator", namespaced: true, args: []string{"--", "--cc-platform"}, claude: []string{"--cc-platform"}},
+
+		// claude 需要值的参数，其值不会被当作 ccgate 参数
+		{name: "claude value looks like flag", args: []string{"--append-system-prompt", "-y", "--add-dir", "-p"},
+			claude: []string{"--append-system-prompt", "-y", "--add-dir", "-p"}},
+		{name: "unknown claude flags", args: []string{"--brand-new-flag", "x", "-Z"}, claude: []string{"--brand-new-flag", "x", "-Z"}},
+		{name: "positional and stdin dash", args: []string{"fix the bug", "-"}, claude: []string{"fix the bug", "-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetRootFlags(t)
+			got, err := parseRootArgs(rootCmd, tt.args, tt.namespaced)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(got.Claude, tt.claude) {
+				t.Errorf("claude args: expected %q, got %q", tt.claude, got.Claude)
+			}
+			if platformName != tt.platform || skipConfirm != tt.yes || cfgFile != tt.config ||
+				outputFormat != tt.output || dryRun != tt.dryRun {
+				t.Errorf("ccgate flags: got platform=%q yes=%v config=%q output=%q dry-run=%v",
+					platformName, skipConfirm, cfgFile, outputFormat, dryRun)
+			}
+			if got.Help != tt.help || got.ShortPlatform != tt.short {
+				t.Errorf("expected help=%v short=%v, got %+v", tt.help, tt.short, got)
+			}
+			if tt.warn == "" && len(got.Warnings) > 0 {
+				t.Errorf("expected no warnings, got %q", got.Warnings)
+			}
+			if tt.warn != "" && (len(got.Warnings) != 1 || !strings.Contains(got.Warnings[0], tt.warn)) {
+				t.Errorf("expected one warning containing %q, got %q", tt.warn, got.Warnings)
+			}
+		})
+	}
+}
+
+// TestParseRootArgsClaudeFlags tests that every claude flag is passed through unchanged
+func TestParseRootArgsClaudeFlags(t *testing.T) {
+	for _, f := range claudeFlags {
+		forms := [][]string{{"--" + f.Name}}
+		if f.TakesValue {
+			forms = [][]string{{"--" + f.Name, "value"}, {"--" + f.Name + "=value"}, {"--" + f.Name, "-y"}}
+		}
+		// -p 与 -h 与 ccgate 冲突，由 TestParseRootArgs 覆盖
+		if f.Shorthand != "" && f.Shorthand != "p" && f.Shorthand != "h" {
+			forms = append(forms, []string{"-" + f.Shorthand})
+		}
+		if f.Name == "help" {
+			forms = nil
+		}
+
+		for _, form := range forms {
+			resetRootFlags(t)
+			args := append([]string{"--platform", "prod"}, form...)
+			args = append(args, "prompt")
+			got, err := parseRootArgs(rootCmd, args, false)
+			if err != nil {
+				t.Fatalf("%q: expected no error, got %v", form, err)
+			}
+			want := append(append([]string{}, form...), "prompt")
+			if !reflect.DeepEqual(got.Claude, want) || platformName != "prod" || skipConfirm || len(got.Warnings) > 0 {
+				t.Errorf("%q: expected claude args %q, got %q (platform=%q yes=%v warnings=%q)",
+					form, want, got.Claude, platformName, skipConfirm, got.Warnings)
+			}
+		}
+	}
+}
+
+// TestParseRootArgsErrors tests invalid ccgate flags
+func TestParseRootArgsErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"--cc-unknown"},
+		{"--platform"},
+		{"-f"},
+		{"--yes=maybe"},
+	} {
+		resetRootFlags(t)
+		if _, err := parseRootArgs(rootCmd, args, false); err == nil {
+			t.Errorf("%q: expected error, got nil", args)
+		}
+	}
+}
@@ -0,0 +1,581 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// bundleVersion 平台包格式版本
+const bundleVersion = 1
+
+// bundleKDFIterations 口令派生密钥的迭代次数
+const bundleKDFIterations = 600000
+
+// 导入平台包时的冲突处理策略
+const (
+	strategySkip      = "skip"
+	strategyOverwrite = "overwrite"
+	strategyRename    = "rename"
+	strategyPrompt    = "prompt"
+)
+
+var (
+	exportPlatforms  []string
+	exportRedact     bool
+	exportPassphrase string
+	exportFile       string
+
+	importStrategy      string
+	importDryRun        bool
+	importPassphrase    string
+	importAllowCommands bool
+)
+
+// Bundle 表示用于在机器和成员之间共享平台的文件
+// 明文包直接包含 platforms；加密包的 platforms 位于 Ciphertext 中
+type Bundle struct {
+	Version    int               `json:"ccgate_bundle"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Redacted   bool              `json:"redacted,omitempty"`
+	Platforms  []Platform        `json:"platforms,omitempty"`
+	Encryption *BundleEncryption `json:"encryption,omitempty"`
+	Ciphertext []byte            `json:"ciphertext,omitempty"`
+}
+
+// BundleEncryption 描述加密包的密钥派生和加密参数
+type BundleEncryption struct {
+	Cipher     string `json:"cipher"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+}
+
+// export 子命令
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出平台为可共享的平台包",
+	Long: `将平台导出为可移植的平台包文件，用于在机器之间或团队成员之间共享。
+
+  --redact               不包含认证令牌和 env 中名称含 KEY、TOKEN、SECRET 的值，导入时需要重新填写
+  --encrypt-to <口令>    使用口令加密整个平台包（AES-256-GCM）
+
+示例:
+  ccgate export --platforms prod,staging --file team.ccgate
+  ccgate export --redact > platforms.json
+  ccgate export --platforms prod --encrypt-to 'correct horse' --file prod.ccgate`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportRedact && exportPassphrase != "" {
+			return fmt.Errorf("--redact 与 --encrypt-to 不能同时使用")
+		}
+
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		platforms, err := selectExportPlatforms(config, exportPlatforms)
+		if err != nil {
+			return err
+		}
+
+		bundle, err := buildBundle(platforms, exportRedact, exportPassphrase)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(bundle, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化平台包失败: %w", err)
+		}
+		data = append(data, '\n')
+
+		if exportFile == "" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(exportFile, data, 0o600); err != nil {
+			return fmt.Errorf("写入平台包 %s 失败: %w", exportFile, err)
+		}
+		DisplaySuccess(fmt.Sprintf("已导出 %d 个平台到: %s", len(platforms), exportFile), DefaultTheme())
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringSliceVar(&exportPlatforms, "platforms", nil, "要导出的平台（逗号分隔，默认全部）")
+	exportCmd.Flags().BoolVar(&exportRedact, "redact", false, "不导出认证令牌和 env 中的机密")
+	exportCmd.Flags().StringVar(&exportPassphrase, "encrypt-to", "", "使用口令加密平台包")
+	exportCmd.Flags().StringVar(&exportFile, "file", "", "输出文件（默认输出到标准输出）")
+}
+
+// selectExportPlatforms 根据名称列表选择要导出的平台，列表为空时导出全部
+func selectExportPlatforms(config *Config, names []string) ([]Platform, error) {
+	if len(names) == 0 {
+		if len(config.Platforms) == 0 {
+			return nil, NewUserError("没有可导出的平台", "请先运行 'ccgate add' 添加平台")
+		}
+		return config.Platforms, nil
+	}
+
+	platforms := make([]Platform, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		platform, err := findPlatformByName(config.Platforms, name)
+		if err != nil {
+			return nil, unknownPlatformError(config.Platforms, name, err)
+		}
+		platforms = append(platforms, *platform)
+	}
+	return platforms, nil
+}
+
+// redactSecretEnv 返回 env 的副本，其中名称像机密的变量值被清空，导入时需要重新填写
+func redactSecretEnv(env map[string]string) map[string]string {
+	if len(env) == 0 {
+		return env
+	}
+	redacted := make(map[string]string, len(env))
+	for key, value := range env {
+		if isSecretEnvKey(key) {
+			value = ""
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// buildBundle 构建平台包，可选择去除令牌（以及 env 中的机密）或使用口令加密
+func buildBundle(platforms []Platform, redact bool, passphrase string) (*Bundle, error) {
+	bundle := &Bundle{
+		Version:   bundleVersion,
+		CreatedAt: time.Now().UTC(),
+		Redacted:  redact,
+	}
+
+	exported := make([]Platform, len(platforms))
+	copy(exported, platforms)
+	if redact {
+		for i := range exported {
+			exported[i].AnthropicAuthToken = ""
+			exported[i].Env = redactSecretEnv(exported[i].Env)
+		}
+	}
+
+	if passphrase == "" {
+		bundle.Platforms = exported
+		return bundle, nil
+	}
+
+	plaintext, err := json.Marshal(exported)
+	if err != nil {
+		return nil, fmt.Errorf("序列化平台失败: %w", err)
+	}
+
+	enc := &BundleEncryption{
+		Cipher:     "aes-256-gcm",
+		KDF:        "pbkdf2-sha256",
+		Iterations: bundleKDFIterations,
+		Salt:       make([]byte, 16),
+	}
+	if _, err := rand.Read(enc.Salt); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %w", err)
+	}
+
+	gcm, err := bundleCipher(passphrase, enc)
+	if err != nil {
+		return nil, err
+	}
+	enc.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(enc.Nonce); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %w", err)
+	}
+
+	bundle.Encryption = enc
+	bundle.Ciphertext = gcm.Seal(nil, enc.Nonce, plaintext, nil)
+	return bundle, nil
+}
+
+// bundleCipher 根据口令和加密参数创建 AES-GCM
+func bundleCipher(passphrase string, enc *BundleEncryption) (cipher.AEAD, error) {
+	if enc.Cipher != "aes-256-gcm" || enc.KDF != "pbkdf2-sha256" {
+		return nil, NewConfigError(
+			fmt.Sprintf("不支持的加密方式: %s / %s", enc.Cipher, enc.KDF),
+			"请升级 ccgate 后重试")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, enc.Salt, enc.Iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// readBundle 读取并在需要时解密平台包
+func readBundle(path, passphrase string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取平台包 %s 失败: %w", path, err)
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil || bundle.Version == 0 {
+		return nil, NewConfigError(
+			fmt.Sprintf("%s 不是有效的 ccgate 平台包", path),
+			"请使用 'ccgate export' 生成平台包")
+	}
+	if bundle.Version > bundleVersion {
+		return nil, NewConfigError(
+			fmt.Sprintf("平台包版本 %d 高于当前支持的版本 %d", bundle.Version, bundleVersion),
+			"请升级 ccgate 后重试")
+	}
+	if bundle.Encryption == nil {
+		return &bundle, nil
+	}
+
+	if passphrase == "" {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return nil, NewUserError("平台包已加密", "使用 --passphrase 提供口令")
+		}
+		passphrase, err = pterm.DefaultInteractiveTextInput.
+			WithDefaultText("平台包已加密，请输入口令").
+			WithMask("*").
+			Show()
+		if err != nil {
+			return nil, fmt.Errorf("获取口令失败: %w", err)
+		}
+	}
+
+	gcm, err := bundleCipher(passphrase, bundle.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, bundle.Encryption.Nonce, bundle.Ciphertext, nil)
+	if err != nil {
+		return nil, NewUserError("平台包解密失败", "请确认口令正确，且文件未被修改")
+	}
+	if err := json.Unmarshal(plaintext, &bundle.Platforms); err != nil {
+		return nil, fmt.Errorf("解析平台包内容失败: %w", err)
+	}
+	return &bundle, nil
+}
+
+// bundleAction 表示导入单个平台时的处理方式
+type bundleAction struct {
+	Platform Platform
+	Original string // 平台包中的原名称
+	Action   string // add / overwrite / skip / rename
+	// Commands 平台包中该平台会在启动时执行的 shell 命令，Stripped 表示导入时已去除
+	Commands []string
+	Stripped bool
+	// DroppedFallback 导入后不存在的备用平台，已从 fallback 中去除
+	DroppedFallback []string
+}
+
+// planBundleImport 根据冲突策略生成导入计划
+// prompt 策略只在确实出现名称冲突时才需要交互，没有冲突时可以在脚本中使用
+func planBundleImport(config *Config, platforms []Platform, strategy string) ([]bundleAction, error) {
+	taken := make(map[string]bool)
+	for _, p := range config.Platforms {
+		taken[p.Name] = true
+	}
+
+	plan := make([]bundleAction, 0, len(platforms))
+	for _, p := range platforms {
+		action := bundleAction{Platform: p, Original: p.Name, Action: "add"}
+
+		if taken[p.Name] {
+			choice := strategy
+			if choice == strategyPrompt {
+				if !term.IsTerminal(int(os.Stdin.Fd())) {
+					return nil, NewUserError(
+						fmt.Sprintf("平台 '%s' 已存在，当前环境无法交互式处理名称冲突", p.Name),
+						"使用 --strategy skip|overwrite|rename 指定策略")
+				}
+				selected, err := pterm.DefaultInteractiveSelect.
+					WithOptions([]string{strategySkip, strategyOverwrite, strategyRename}).
+					WithDefaultText(fmt.Sprintf("平台 '%s' 已存在，如何处理?", p.Name)).
+					Show()
+				if err != nil {
+					return nil, fmt.Errorf("获取冲突处理方式失败: %w", err)
+				}
+				choice = selected
+			}
+
+			switch choice {
+			case strategySkip:
+				action.Action = "skip"
+			case strategyOverwrite:
+				action.Action = "overwrite"
+			case strategyRename:
+				action.Action = "rename"
+				action.Platform.Name = uniquePlatformName(p.Name, taken)
+			}
+		}
+
+		if action.Action != "skip" {
+			taken[action.Platform.Name] = true
+		}
+		plan = append(plan, action)
+	}
+	return plan, nil
+}
+
+// resolveBundleFallback 调整导入平台的 fallback：指向同一平台包中被重命名的平台时改用新名称，
+// 导入后仍不存在的平台从 fallback 中去除，避免导入后配置无效
+func resolveBundleFallback(config *Config, plan []bundleAction) {
+	exists := make(map[string]bool)
+	for _, p := range config.Platforms {
+		exists[p.Name] = true
+	}
+	renamed := make(map[string]string)
+	for _, a := range plan {
+		if a.Action == "skip" {
+			continue
+		}
+		exists[a.Platform.Name] = true
+		renamed[a.Original] = a.Platform.Name
+	}
+
+	for i := range plan {
+		a := &plan[i]
+		if a.Action == "skip" || len(a.Platform.Fallback) == 0 {
+			continue
+		}
+		var fallback []string
+		for _, name := range a.Platform.Fallback {
+			if to, ok := renamed[name]; ok {
+				name = to
+			}
+			if !exists[name] || name == a.Platform.Name {
+				a.DroppedFallback = append(a.DroppedFallback, name)
+				continue
+			}
+			fallback = append(fallback, name)
+		}
+		a.Platform.Fallback = fallback
+	}
+}
+
+// platformCommands 返回平台在启动时会执行的 shell 命令（钩子和 token_command）
+func platformCommands(p *Platform) []string {
+	var commands []string
+	if p.Hooks != nil {
+		for _, c := range p.Hooks.PreLaunch {
+			commands = append(commands, hookPreLaunch+": "+c)
+		}
+		for _, c := range p.Hooks.PostLaunch {
+			commands = append(commands, hookPostLaunch+": "+c)
+		}
+	}
+	if p.TokenCommand != "" {
+		commands = append(commands, "token_command: "+p.TokenCommand)
+	}
+	return commands
+}
+
+// applyBundleCommandPolicy 记录平台包中的命令，未指定 --allow-commands 时去除这些命令
+// 平台包可能来自他人，其中的钩子和 token_command 会在下次启动时执行，因此默认不导入
+func applyBundleCommandPolicy(plan []bundleAction, allow bool) {
+	for i := range plan {
+		a := &plan[i]
+		a.Commands = platformCommands(&a.Platform)
+		if len(a.Commands) == 0 || allow || a.Action == "skip" {
+			continue
+		}
+		a.Platform.Hooks = nil
+		a.Platform.TokenCommand = ""
+		a.Platform.TokenTTL = ""
+		a.Stripped = true
+	}
+}
+
+// uniquePlatformName 生成不冲突的平台名称，如 prod-2
+func uniquePlatformName(name string, taken map[string]bool) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// importBundle 导入平台包
+func importBundle(path string) error {
+	theme := DefaultTheme()
+
+	switch importStrategy {
+	case strategySkip, strategyOverwrite, strategyRename, strategyPrompt:
+	default:
+		return NewUserError(
+			fmt.Sprintf("不支持的冲突策略: %s", importStrategy),
+			"--strategy 可选值为 skip、overwrite、rename、prompt")
+	}
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+
+	bundle, err := readBundle(path, importPassphrase)
+	if err != nil {
+		return err
+	}
+	if len(bundle.Platforms) == 0 {
+		DisplayWarning("平台包中没有平台", theme)
+		return nil
+	}
+
+	config, err := loadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	plan, err := planBundleImport(config, bundle.Platforms, importStrategy)
+	if err != nil {
+		return err
+	}
+	applyBundleCommandPolicy(plan, importAllowCommands)
+	resolveBundleFallback(config, plan)
+	printBundlePlan(plan, theme)
+
+	if importDryRun {
+		DisplayInfo("dry-run 模式，未写入任何配置", theme)
+		return nil
+	}
+
+	imported := 0
+	for _, action := range plan {
+		if action.Action == "skip" {
+			continue
+		}
+		platform := action.Platform
+
+		// 去除令牌的平台包需要重新填写令牌
+		if platform.AnthropicAuthToken == "" && interactive {
+			token, err := pterm.DefaultInteractiveTextInput.
+				WithDefaultText(fmt.Sprintf("请输入平台 '%s' 的认证令牌", platform.Name)).
+				WithMask("*").
+				Show()
+			if err != nil {
+				return fmt.Errorf("获取认证令牌失败: %w", err)
+			}
+			platform.AnthropicAuthToken = strings.TrimSpace(token)
+		}
+		missing := missingSecretEnv(platform.Env)
+		if len(missing) > 0 && !interactive {
+			DisplayWarning(fmt.Sprintf("平台 '%s' 的 %s 在平台包中已清空，请导入后编辑配置填写", platform.Name, strings.Join(missing, "、")), theme)
+		} else {
+			for _, key := range missing {
+				value, err := pterm.DefaultInteractiveTextInput.
+					WithDefaultText(fmt.Sprintf("请输入平台 '%s' 的 %s", platform.Name, key)).
+					WithMask("*").
+					Show()
+				if err != nil {
+					return fmt.Errorf("获取 %s 失败: %w", key, err)
+				}
+				platform.Env[key] = strings.TrimSpace(value)
+			}
+		}
+
+		if err := platform.Validate(); err != nil {
+			DisplayWarning(fmt.Sprintf("跳过平台 '%s': %v", platform.Name, err), theme)
+			continue
+		}
+
+		config.Platforms = updateOrAddPlatform(config.Platforms, platform)
+		imported++
+	}
+
+	if imported == 0 {
+		DisplayWarning("没有导入任何平台", theme)
+		return nil
+	}
+	if err := saveConfig(config, cfgFile); err != nil {
+		return err
+	}
+	DisplaySuccess(fmt.Sprintf("已导入 %d 个平台", imported), theme)
+	return nil
+}
+
+// missingSecretEnv 返回 env 中值为空的机密变量名（已排序），即 --redact 导出时清空的变量
+func missingSecretEnv(env map[string]string) []string {
+	var missing []string
+	for _, key := range sortedKeys(env) {
+		if env[key] == "" && isSecretEnvKey(key) {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// printBundlePlan 输出导入计划
+func printBundlePlan(plan []bundleAction, theme *Theme) {
+	labels := map[string]string{
+		"add":       theme.Colors.Success.Sprint("新增"),
+		"overwrite": theme.Colors.Warning.Sprint("覆盖"),
+		"skip":      theme.Colors.Muted.Sprint("跳过"),
+		"rename":    theme.Colors.Info.Sprint("重命名"),
+	}
+
+	tableData := pterm.TableData{{"平台", "操作", "导入为", "Base URL", "令牌"}}
+	for _, a := range plan {
+		token := maskToken(a.Platform.AnthropicAuthToken)
+		if a.Platform.AnthropicAuthToken == "" {
+			token = theme.Colors.Warning.Sprint("需要填写")
+		}
+		tableData = append(tableData, []string{
+			a.Original,
+			labels[a.Action],
+			a.Platform.Name,
+			a.Platform.AnthropicBaseURL,
+			token,
+		})
+	}
+
+	pterm.DefaultTable.WithHasHeader(true).
+		WithBoxed(true).
+		WithData(tableData).
+		Render()
+	Spacer(theme.Spacing.XS, theme)
+
+	// 列出平台包中的所有命令，导入前必须让用户看到
+	stripped := false
+	for _, a := range plan {
+		if len(a.Commands) == 0 || a.Action == "skip" {
+			continue
+		}
+		status := theme.Colors.Warning.Sprint("将导入，启动时会执行")
+		if a.Stripped {
+			status = theme.Colors.Muted.Sprint("已去除")
+			stripped = true
+		}
+		fmt.Printf("平台 '%s' 包含以下命令（%s）:\n", a.Platform.Name, status)
+		for _, c := range a.Commands {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+	if stripped {
+		DisplayWarning("平台包中的 hooks 和 token_command 默认不导入；确认命令可信后使用 --allow-commands 导入", theme)
+		Spacer(theme.Spacing.XS, theme)
+	}
+
+	for _, a := range plan {
+		if len(a.DroppedFallback) > 0 {
+			DisplayWarning(fmt.Sprintf("平台 '%s' 的备用平台 %s 在导入后不存在，已从 fallback 中去除",
+				a.Platform.Name, strings.Join(a.DroppedFallback, "、")), theme)
+		}
+	}
+}
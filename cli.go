@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -14,6 +15,15 @@ var (
 	cfgFile      string
 	platformName string
 	skipConfirm  bool
+	dryRun       bool
+	showToken    bool
+	supervise    bool
+	// recordSession 在 pty 中运行 claude 并录制会话
+	recordSession bool
+	listFormat    string
+
+	// claudeModelFlag 仅用于补全 claude 的 --model 参数，值仍会原样传递给 claude
+	claudeModelFlag string
 
 	// 版本信息（通过 ldflags 在构建时注入）
 	Version   = "v0.0.0"
@@ -28,50 +38,123 @@ var rootCmd = &cobra.Command{
 	Long: `ccgate 是一个 Claude Code 平台配置管理工具，同时也是 claude 命令的透明代理。
 
 它提供以下功能：
-1. 管理多个 Claude 平台配置（list, add, delete）
+1. 管理多个 Claude 平台配置（list, add, delete, use, current）
 2. 透明代理 claude 命令，自动设置环境变量
+3. 使用平台环境变量执行任意命令（exec）或启动子 shell（shell）
+
+参数传递：
+  -p/--platform、-y/--yes、-f/--config、--output、--dry-run、--supervise、--record 由 ccgate 解析，
+  其余参数原样传递给 claude；"--" 之后的参数全部传递给 claude。
+  每个 ccgate 参数都有不会与 claude 冲突的 --cc-* 形式（如 --cc-platform）；
+  设置 CCGATE_FLAG_MODE=namespaced 后只识别 --cc-* 参数，-p 等全部交给 claude。
 
 示例:
   ccgate list                    # 列出所有平台
+  ccgate list --output json      # 以 JSON 输出平台列表（令牌已掩码）
+  ccgate -p prod --dry-run       # 查看将使用的平台、环境变量和命令
+  ccgate init                    # 首次使用向导
   ccgate add                     # 添加新平台
+  ccgate add --preset kimi       # 使用厂商预设添加平台
+  ccgate import --from-env --name work  # 从当前环境变量导入平台
+  ccgate export --redact --file team.ccgate  # 导出不含令牌的平台包
   ccgate -p prod --continue      # 使用 prod 平台继续对话
+  ccgate --cc-platform prod -p "总结改动"  # --cc-* 参数不会与 claude 的 -p（print）冲突
+  ccgate -p prod -- -p "总结改动"  # -- 之后的参数全部原样传递给 claude
   ccgate --continue              # 交互式选择平台后继续对话
-  ccgate chat "hello"            # 交互式选择平台后开始新对话`,
+  ccgate use prod --local        # 当前目录默认使用 prod 平台
+  ccgate apply prod              # 将 prod 写入 ~/.claude/settings.json 供 IDE 使用
+  ccgate doctor                  # 诊断常见的启动问题
+  ccgate test --all              # 测试所有平台的连通性和认证
+  ccgate models prod             # 列出 prod 平台支持的模型
+  ccgate history -p prod         # 查看 prod 平台的启动记录
+  ccgate stats                   # 按平台、日期和仓库统计使用情况
+  ccgate diff prod staging       # 比较两个平台的配置
+  ccgate config diff             # 查看相对于上次保存前的配置变化
+  ccgate token refresh gateway   # 重新运行 token_command 获取短期令牌
+  ccgate -p prod --record        # 录制会话（令牌已脱敏），之后用 ccgate sessions play 回放
+  ccgate completion install      # 安装 shell 自动补全
+  ccgate chat "hello"            # 交互式选择平台后开始新对话
+  ccgate exec -p prod -- env     # 使用 prod 平台环境执行任意命令
+  ccgate shell -p prod           # 启动使用 prod 平台环境的子 shell`,
 
 	// 禁用默认的 completion 命令（我们自己实现）
 	CompletionOptions: cobra.CompletionOptions{
 		DisableDefaultCmd: false,
 	},
 
-	// 关键：允许未知 flags 和参数
-	FParseErrWhitelist: cobra.FParseErrWhitelist{
-		UnknownFlags: true,
-	},
-	DisableFlagParsing: false, // 保持 flag 解析，但允许未知 flags
+	// 关键：根命令的参数由 parseRootArgs 拆分，ccgate 参数之外的全部传递给 claude
+	// 补全请求时 Execute 会重新开启 cobra 的解析，以便补全 -p、--model 等参数的值
+	DisableFlagParsing: true,
 
 	// 禁用参数验证，允许任意参数
 	Args: cobra.ArbitraryArgs,
 
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateOutputFormat()
+	},
+
 	RunE: handleRootCommand,
 }
 
 func init() {
 	// 全局 flags（这些不会传递给 claude）
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "f", "", "指定配置文件路径")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "", "输出格式（json|yaml），用于 list、show、version、doctor、diff 和 --dry-run")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只显示将使用的平台、环境变量和命令，不启动 claude")
+	rootCmd.Flags().BoolVar(&supervise, "supervise", false, "以托管子进程方式启动 claude（记录退出码和时长）")
+	rootCmd.Flags().BoolVar(&recordSession, "record", false, "在 pty 中运行 claude 并录制会话到 ~/.ccgate/sessions/（仅类 Unix 系统）")
 	rootCmd.Flags().StringVarP(&platformName, "platform", "p", "", "指定平台名称")
 	rootCmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "跳过确认提示")
+	rootCmd.Flags().StringVar(&claudeModelFlag, "model", "", "传递给 claude 的模型名称")
+	rootCmd.Flags().MarkHidden("model")
+	registerNamespacedFlags(rootCmd)
+
+	listCmd.Flags().BoolVar(&showToken, "show-token", false, "结构化输出中显示完整令牌和 env 值")
+	listCmd.Flags().StringVar(&listFormat, "format", "", "使用 Go 模板格式化每个平台（以 table 开头时按列对齐）")
+	showCmd.Flags().BoolVar(&showToken, "show-token", false, "显示完整令牌和 env 值")
 
 	// 添加子命令
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(useCmd)
+	rootCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(modelsCmd)
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(tokenCmd)
+	rootCmd.AddCommand(sessionsCmd)
+	rootCmd.AddCommand(fanoutCmd)
 }
 
 // handleRootCommand 处理根命令（透明代理逻辑）
 func handleRootCommand(cmd *cobra.Command, args []string) error {
-	// 提取 claude 参数（排除 ccgate 专有的 flags）
-	claudeArgs := extractClaudeArgs(os.Args[1:])
+	// 拆分 ccgate 参数与 claude 参数
+	parsed, err := parseRootArgs(cmd, args, namespacedFlagMode())
+	if err != nil {
+		return err
+	}
+	if parsed.Help {
+		return cmd.Help()
+	}
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+	displayArgWarnings(parsed.Warnings)
+	claudeArgs := parsed.Claude
 
 	// 加载配置
 	config, err := loadConfig(cfgFile)
@@ -81,140 +164,200 @@ func handleRootCommand(cmd *cobra.Command, args []string) error {
 
 	// 验证配置
 	if len(config.Platforms) == 0 {
+		if dryRun && structuredOutput() {
+			return NewUserError("没有配置任何平台", "请先运行 'ccgate init' 或 'ccgate add' 添加平台")
+		}
 		theme := DefaultTheme()
 		DisplayWarning("没有配置任何平台", theme)
-		fmt.Println("请先运行 'ccgate add' 添加平台")
+		fmt.Println("请先运行 'ccgate init' 或 'ccgate add' 添加平台")
 		return nil
 	}
 
-	// 选择平台（-p 指定 或 交互式）
-	// 多平台交互式选择时内部会处理确认循环（支持 ESC 返回）
-	// 其他情况（-p 指定或单平台）在外部确认
-	platform, err := selectPlatform(config, platformName, claudeArgs, skipConfirm)
-	if err != nil {
+	if err := checkConfig(config); err != nil {
 		return err
 	}
 
-	// 当使用 -p 指定平台 或 单平台自动选择时，需要确认（除非 --yes）
-	// 交互式多平台选择时内部已经处理了确认
-	if platformName != "" || len(config.Platforms) == 1 {
-		if err := confirmExecution(platform, claudeArgs, skipConfirm); err != nil {
-			return err
+	// -p 的值不是平台名称时，多半是 claude 的 print 模式被当成了平台参数
+	if parsed.ShortPlatform {
+		if _, err := findPlatformByName(config.Platforms, platformName); err != nil {
+			return NewValidationError(
+				fmt.Sprintf("平台 '%s' 不存在（-p 是 ccgate 的平台参数）", platformName),
+				"如果要使用 claude 的 print 模式，请写成 'ccgate --cc-platform <平台> -p ...' 或 'ccgate -p <平台> -- -p ...'；运行 'ccgate list' 查看所有平台")
 		}
 	}
 
-	// 透明代理到 claude
-	return proxyToClaude(platform, claudeArgs)
-}
-
-// extractClaudeArgs 从原始参数中提取要传递给 claude 的参数
-func extractClaudeArgs(rawArgs []string) []string {
-	var claudeArgs []string
-	skip := false
-
-	for _, arg := range rawArgs {
-		if skip {
-			skip = false
-			continue
-		}
-
-		// 跳过 ccgate 专有 flags
-		switch arg {
-		case "--config", "-f", "--platform", "-p":
-			skip = true // 跳过下一个参数（值）
-			continue
-		case "--yes", "-y":
-			continue // 仅跳过当前参数
-		case "-h", "--help":
-			// help 不传递，由 cobra 处理
-			continue
-		default:
-			// 检查是否为组合短选项（如 -py）
-			if len(arg) > 1 && arg[0] == '-' && arg[1] != '-' {
-				// 可能是组合短选项，需要分解
-				filtered := filterCombinedShortFlags(arg)
-				if filtered != "" {
-					claudeArgs = append(claudeArgs, filtered)
-				}
-			} else {
-				claudeArgs = append(claudeArgs, arg)
-			}
-		}
+	// 选择平台（-p、环境变量、目录绑定、规则、默认平台、唯一平台 或 交互式）
+	// 多平台交互式选择时内部会处理确认循环（支持 ESC 返回）
+	// 其他自动确定的情况在外部确认
+	// dry-run 不会启动 claude，因此无需确认
+	res, err := selectPlatform(config, platformName, claudeArgs, skipConfirm || dryRun)
+	if err != nil {
+		return err
 	}
 
-	return claudeArgs
-}
+	if dryRun {
+		return printDryRun(config, res, claudeArgs)
+	}
 
-// filterCombinedShortFlags 过滤组合短选项中的 ccgate 专有 flags
-func filterCombinedShortFlags(combined string) string {
-	if len(combined) < 2 || combined[0] != '-' {
-		return combined
+	// 配置了备用平台时先做健康检查，失败时切换或在确认界面中提供备用平台
+	res, failover, confirmed, err := checkFailover(config, res, claudeArgs, skipConfirm, probeHealth)
+	if err != nil {
+		return err
 	}
 
-	// 移除 ccgate 专有的短选项: p, y, f
-	filtered := "-"
-	for i := 1; i < len(combined); i++ {
-		c := combined[i]
-		if c != 'p' && c != 'y' && c != 'f' {
-			filtered += string(c)
+	// 自动确定平台时需要说明依据并确认（除非 --yes）
+	// 交互式多平台选择时内部已经处理了确认
+	if res.Source != SourceInteractive && !confirmed {
+		if res.Source != SourceFlag {
+			DisplayInfo(res.Summary(), DefaultTheme())
+		}
+		if err := confirmExecution(res.Platform, claudeArgs, skipConfirm); err != nil {
+			return err
 		}
 	}
 
-	if filtered == "-" {
-		return ""
+	// 透明代理到 claude
+	req, err := newLaunchRequest(config, res, claudeArgs)
+	if err != nil {
+		return err
 	}
-	return filtered
+	req.Failover = failover
+	return proxyToClaude(req)
 }
 
 // list 子命令
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "列出所有可用平台",
+	Long: `列出所有可用平台。
+
+使用 --output json|yaml 输出结构化数据（令牌和 env 中名称包含 KEY、TOKEN、SECRET 的值默认掩码，
+--show-token 输出完整值）。
+
+使用 --format 按 Go 模板逐行输出，可用 Platform 的全部字段（Name、Vendor、
+AnthropicBaseURL、AnthropicModel、AnthropicSmallModel、AuthMode、Env 等）
+以及计算字段：Index、MaskedToken、TokenFingerprint、TokenEnv、Default、LastUsed。
+辅助函数：pad、lpad、truncate、upper、lower、join、default、ago、json。
+以 "table " 开头时按列对齐并根据字段名生成表头。
+
+示例:
+  ccgate list --format '{{.Name}}\t{{.AnthropicModel}}'
+  ccgate list --format 'table {{.Name}}\t{{.AnthropicModel}}\t{{ago .LastUsed}}'
+  ccgate list --format '{{pad 12 .Name}} {{.MaskedToken}}{{if .Default}} *{{end}}'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		config, err := loadConfig(cfgFile)
 		if err != nil {
 			return fmt.Errorf("加载配置失败: %w", err)
 		}
+		if listFormat != "" {
+			if structuredOutput() {
+				return NewUserError("--format 不能与 --output 同时使用", "二者选其一")
+			}
+			history, err := loadHistory()
+			if err != nil {
+				return err
+			}
+			return renderPlatformTemplate(os.Stdout, listFormat, config.Platforms, config, lastUsedByPlatform(history))
+		}
+		if structuredOutput() {
+			out := make([]platformOutput, len(config.Platforms))
+			for i := range config.Platforms {
+				out[i] = newPlatformOutput(&config.Platforms[i], config, showToken)
+			}
+			return writeStructured(out)
+		}
 		listPlatforms(config.Platforms)
 		return nil
 	},
 }
 
+// show 子命令
+var showCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "显示平台的详细配置",
+	Long: `显示平台的详细配置，省略名称时显示当前会使用的平台。
+
+令牌和 env 中名称包含 KEY、TOKEN、SECRET 的值默认掩码，使用 --show-token 显示完整值。
+
+示例:
+  ccgate show prod
+  ccgate show prod --output yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		var platform *Platform
+		if len(args) == 1 {
+			platform, err = findPlatformByName(config.Platforms, args[0])
+			if err != nil {
+				return unknownPlatformError(config.Platforms, args[0], err)
+			}
+		} else {
+			res, err := selectPlatform(config, "", nil, true)
+			if err != nil {
+				return err
+			}
+			platform = res.Platform
+		}
+
+		out := newPlatformOutput(platform, config, showToken)
+		if structuredOutput() {
+			return writeStructured(out)
+		}
+		renderPlatformTable(platform, showToken, DefaultTheme())
+		return nil
+	},
+}
+
 // add 子命令
 var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "添加或更新平台配置",
 	Long: `交互式添加新平台或更新现有平台配置。
 
-如果平台名称已存在，将更新该平台的配置。`,
+如果平台名称已存在，将更新该平台的配置。
+使用 --preset 时只需填写名称和令牌，其余配置来自厂商预设。
+
+示例:
+  ccgate add
+  ccgate add --preset kimi`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		config, err := loadConfig(cfgFile)
 		if err != nil {
 			return fmt.Errorf("加载配置失败: %w", err)
 		}
 
+		if addPreset != "" {
+			presets, err := loadPresets()
+			if err != nil {
+				return err
+			}
+			preset, err := findPreset(presets, addPreset)
+			if err != nil {
+				return err
+			}
+			platform, err := promptPresetPlatform(preset, config)
+			if err != nil {
+				return err
+			}
+			return savePresetPlatform(config, platform)
+		}
+
 		newPlatform, err := addPlatform()
 		if err != nil {
 			return err
 		}
 
-		// 检查是否已存在
-		existingIndex := -1
-		for i, p := range config.Platforms {
-			if p.Name == newPlatform.Name {
-				existingIndex = i
-				break
-			}
-		}
-
 		theme := DefaultTheme()
-		if existingIndex != -1 {
-			config.Platforms[existingIndex] = newPlatform
+		if _, err := findPlatformByName(config.Platforms, newPlatform.Name); err == nil {
 			DisplayWarning(fmt.Sprintf("平台 '%s' 已存在，已更新配置", newPlatform.Name), theme)
 		} else {
-			config.Platforms = append(config.Platforms, newPlatform)
 			DisplaySuccess(fmt.Sprintf("平台 '%s' 添加成功", newPlatform.Name), theme)
 		}
+		config.Platforms = updateOrAddPlatform(config.Platforms, newPlatform)
 
 		return saveConfig(config, cfgFile)
 	},
@@ -238,12 +381,22 @@ var deleteCmd = &cobra.Command{
 		}
 
 		config.Platforms = newPlatforms
+
+		// 与平台一并移除指向它的引用，避免留下无效的配置
+		removed := removePlatformReferences(config, name)
+
 		if err := saveConfig(config, cfgFile); err != nil {
 			return err
 		}
 
 		theme := DefaultTheme()
 		DisplaySuccess(fmt.Sprintf("✓ 平台 '%s' 删除成功", name), theme)
+		if len(removed) > 0 {
+			fmt.Println("同时移除了指向该平台的引用:")
+			for _, r := range removed {
+				fmt.Printf("  - %s\n", r)
+			}
+		}
 		return nil
 	},
 }
@@ -252,20 +405,39 @@ var deleteCmd = &cobra.Command{
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "显示 ccgate 版本信息",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if structuredOutput() {
+			return writeStructured(newVersionOutput())
+		}
 		theme := DefaultTheme()
 		pterm.Info.Printf("%s %s\n", theme.Colors.Primary.Sprint("ccgate version"), Version)
 		fmt.Printf("Commit: %s\n", Commit)
 		fmt.Printf("Build Date: %s\n", BuildDate)
 		fmt.Println("Claude Code 平台管理与透明代理工具")
+		return nil
 	},
 }
 
 // Execute 执行根命令
 func Execute() {
+	registerCompletions()
+	rootCmd.DisableFlagParsing = !isCompletionRequest(os.Args[1:])
+	// exec 的标准输出只属于目标命令，参数错误、选择器等全部写到标准错误
+	if cmd, _, err := rootCmd.Find(os.Args[1:]); err == nil && cmd == execCmd {
+		reserveStdout()
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		theme := DefaultTheme()
-		uiErr := &UIError{
+
+		// 已经是 UIError 的错误保留其类型和恢复建议
+		var uiErr *UIError
+		if errors.As(err, &uiErr) {
+			uiErr.DisplayError(theme)
+			os.Exit(1)
+		}
+
+		uiErr = &UIError{
 			Type:      ErrorTypeSystem,
 			Message:   fmt.Sprintf("错误: %v", err),
 			Severity:  SeverityError,
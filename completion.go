@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// completion 子命令（替代 cobra 默认的 completion 命令）
+var completionCmd = &cobra.Command{
+	Use:   "completion",
+	Short: "生成或安装 shell 自动补全脚本",
+	Long: `生成指定 shell 的自动补全脚本，或使用 install 直接安装到默认位置。
+
+补全支持平台名称（-p/--platform、delete、test 等）以及 --model 的模型名称。
+
+示例:
+  ccgate completion install          # 根据 $SHELL 自动安装
+  ccgate completion install zsh
+  source <(ccgate completion bash)   # 仅在当前 shell 中启用`,
+	Args: cobra.NoArgs,
+}
+
+// completionInstallCmd 安装补全脚本
+var completionInstallCmd = &cobra.Command{
+	Use:       "install [bash|zsh|fish]",
+	Short:     "将补全脚本安装到 shell 的默认加载位置",
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shell := filepath.Base(os.Getenv("SHELL"))
+		if len(args) == 1 {
+			shell = args[0]
+		}
+		return installCompletion(shell)
+	},
+}
+
+func init() {
+	generators := map[string]func(*bytes.Buffer) error{
+		"bash":       func(b *bytes.Buffer) error { return rootCmd.GenBashCompletionV2(b, true) },
+		"zsh":        func(b *bytes.Buffer) error { return rootCmd.GenZshCompletion(b) },
+		"fish":       func(b *bytes.Buffer) error { return rootCmd.GenFishCompletion(b, true) },
+		"powershell": func(b *bytes.Buffer) error { return rootCmd.GenPowerShellCompletionWithDesc(b) },
+	}
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		gen := generators[shell]
+		completionCmd.AddCommand(&cobra.Command{
+			Use:                   shell,
+			Short:                 fmt.Sprintf("输出 %s 的自动补全脚本", shell),
+			Args:                  cobra.NoArgs,
+			DisableFlagsInUseLine: true,
+			ValidArgsFunction:     cobra.NoFileCompletions,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var buf bytes.Buffer
+				if err := gen(&buf); err != nil {
+					return fmt.Errorf("生成补全脚本失败: %w", err)
+				}
+				_, err := cmd.OutOrStdout().Write(buf.Bytes())
+				return err
+			},
+		})
+	}
+	completionCmd.AddCommand(completionInstallCmd)
+}
+
+// registerCompletions 注册动态补全函数
+// 需要在所有子命令的 flags 定义完成后调用，因此不放在 init 中
+func registerCompletions() {
+	// 平台名称补全
+	rootCmd.RegisterFlagCompletionFunc("platform", completePlatformFlag)
+	rootCmd.RegisterFlagCompletionFunc(namespacedFlagPrefix+"platform", completePlatformFlag)
+	for _, cmd := range []*cobra.Command{execCmd, shellCmd, currentCmd, historyCmd, statsCmd} {
+		cmd.RegisterFlagCompletionFunc("platform", completePlatformFlag)
+	}
+	fanoutCmd.RegisterFlagCompletionFunc("platform", completePlatformListFlag)
+	for _, cmd := range []*cobra.Command{deleteCmd, useCmd, testCmd, modelsCmd, tokenRefreshCmd, tokenClearCmd} {
+		cmd.ValidArgsFunction = completePlatformArg
+	}
+
+	// 会话 ID 补全
+	sessionsPlayCmd.ValidArgsFunction = completeSessionArg
+
+	// 厂商预设补全
+	addCmd.RegisterFlagCompletionFunc("preset", completePresetFlag)
+
+	// claude 的 --model 参数补全为已配置的模型
+	rootCmd.RegisterFlagCompletionFunc("model", completeModelFlag)
+}
+
+// loadConfigForCompletion 补全时静默加载配置，失败时返回 nil
+func loadConfigForCompletion(cmd *cobra.Command) *Config {
+	path, _ := cmd.Flags().GetString("config")
+	config, err := loadConfig(path)
+	if err != nil {
+		return nil
+	}
+	return config
+}
+
+// platformCompletions 返回带描述的平台名称补全候选
+func platformCompletions(config *Config, toComplete string) []string {
+	if config == nil {
+		return nil
+	}
+	var out []string
+	for _, p := range config.Platforms {
+		if !strings.HasPrefix(p.Name, toComplete) {
+			continue
+		}
+		desc := p.AnthropicModel
+		if p.Vendor != "" {
+			desc = p.Vendor + " · " + p.AnthropicModel
+		}
+		out = append(out, p.Name+"\t"+desc)
+	}
+	return out
+}
+
+// completePlatformFlag 补全 -p/--platform 的值
+func completePlatformFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return platformCompletions(loadConfigForCompletion(cmd), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePlatformListFlag 补全逗号分隔的平台列表中的最后一项（fanout -p a,b,）
+func completePlatformListFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	done, last := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		done, last = toComplete[:i+1], toComplete[i+1:]
+	}
+	chosen := make(map[string]bool)
+	for _, name := range strings.Split(done, ",") {
+		chosen[name] = true
+	}
+	var out []string
+	for _, c := range platformCompletions(loadConfigForCompletion(cmd), last) {
+		if name, _, _ := strings.Cut(c, "\t"); !chosen[name] {
+			out = append(out, done+c)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completePlatformArg 补全第一个位置参数为平台名称
+func completePlatformArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return platformCompletions(loadConfigForCompletion(cmd), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePresetFlag 补全 add --preset 的值
+func completePresetFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	presets, err := loadPresets()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var out []string
+	for _, p := range presets {
+		if strings.HasPrefix(p.ID, toComplete) {
+			out = append(out, p.ID+"\t"+p.Name)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeModelFlag 补全 --model 的值，已指定 -p 时只列出该平台的模型
+func completeModelFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	config := loadConfigForCompletion(cmd)
+	if config == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	platforms := config.Platforms
+	if name, _ := cmd.Flags().GetString("platform"); name != "" {
+		if p, err := findPlatformByName(config.Platforms, name); err == nil {
+			platforms = []Platform{*p}
+		}
+	}
+
+	descs := make(map[string][]string)
+	for _, p := range platforms {
+		for _, model := range []string{p.AnthropicModel, p.AnthropicSmallModel} {
+			if model != "" && strings.HasPrefix(model, toComplete) {
+				descs[model] = append(descs[model], p.Name)
+			}
+		}
+	}
+
+	out := make([]string, 0, len(descs))
+	for model, names := range descs {
+		out = append(out, model+"\t"+strings.Join(names, ", "))
+	}
+	sort.Strings(out)
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// installCompletion 将补全脚本写入对应 shell 的默认加载位置
+func installCompletion(shell string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	var buf bytes.Buffer
+	var target, hint string
+	switch shell {
+	case "bash":
+		err = rootCmd.GenBashCompletionV2(&buf, true)
+		target = filepath.Join(dataHome, "bash-completion", "completions", "ccgate")
+		hint = "需要安装 bash-completion（v2），新开的 shell 中生效"
+	case "zsh":
+		err = rootCmd.GenZshCompletion(&buf)
+		target = filepath.Join(home, ".zsh", "completions", "_ccgate")
+		hint = "请确保 ~/.zshrc 中包含:\n  fpath=(~/.zsh/completions $fpath)\n  autoload -U compinit && compinit"
+	case "fish":
+		err = rootCmd.GenFishCompletion(&buf, true)
+		target = filepath.Join(configHome, "fish", "completions", "ccgate.fish")
+		hint = "新开的 fish shell 中生效"
+	default:
+		return NewUserError(
+			fmt.Sprintf("不支持自动安装 '%s' 的补全脚本", shell),
+			"请指定 bash、zsh 或 fish，或使用 'ccgate completion <shell>' 手动生成")
+	}
+	if err != nil {
+		return fmt.Errorf("生成补全脚本失败: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("创建目录 %s 失败: %w", filepath.Dir(target), err)
+	}
+	if err := os.WriteFile(target, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("写入补全脚本 %s 失败: %w", target, err)
+	}
+
+	theme := DefaultTheme()
+	DisplaySuccess(fmt.Sprintf("%s 补全脚本已安装到: %s", shell, target), theme)
+	DisplayInfo(hint, theme)
+	return nil
+}
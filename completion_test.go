@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// completionConfig 写入用于补全测试的配置文件
+func completionConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := &Config{Platforms: []Platform{
+		{Name: "kimi", Vendor: "moonshot", AnthropicBaseURL: "https://k", AnthropicAuthToken: "t", AnthropicModel: "k2", AnthropicSmallModel: "k2-turbo"},
+		{Name: "glm", AnthropicBaseURL: "https://g", AnthropicAuthToken: "t", AnthropicModel: "glm-4.6"},
+		{Name: "glm-air", AnthropicBaseURL: "https://g", AnthropicAuthToken: "t", AnthropicModel: "glm-4.6"},
+	}}
+	if err := saveConfig(config, path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestCompletionCandidates tests dynamic completion through the hidden __complete command
+func TestCompletionCandidates(t *testing.T) {
+	cfg := completionConfig(t)
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "root -p", args: []string{"-f", cfg, "-p", ""},
+			want: []string{"kimi\tmoonshot · k2", "glm\tglm-4.6", "glm-air\tglm-4.6", ":4"}},
+		{name: "prefix", args: []string{"-f", cfg, "--platform", "gl"},
+			want: []string{"glm\tglm-4.6", "glm-air\tglm-4.6", ":4"}},
+		{name: "exec -p", args: []string{"exec", "-f", cfg, "-p", "k"},
+			want: []string{"kimi\tmoonshot · k2", ":4"}},
+		{name: "delete arg", args: []string{"delete", "-f", cfg, "glm-"},
+			want: []string{"glm-air\tglm-4.6", ":4"}},
+		{name: "only first arg", args: []string{"use", "-f", cfg, "kimi", ""},
+			want: []string{":4"}},
+		{name: "fanout list", args: []string{"fanout", "-f", cfg, "-p", "glm,"},
+			want: []string{"glm,kimi\tmoonshot · k2", "glm,glm-air\tglm-4.6", ":6"}},
+		{name: "all models", args: []string{"-f", cfg, "--model", ""},
+			want: []string{"glm-4.6\tglm, glm-air", "k2\tkimi", "k2-turbo\tkimi", ":4"}},
+		{name: "models of -p", args: []string{"-f", cfg, "-p", "kimi", "--model", "k2-"},
+			want: []string{"k2-turbo\tkimi", ":4"}},
+		{name: "missing config", args: []string{"-f", filepath.Join(t.TempDir(), "none.json"), "-p", ""},
+			want: []string{":4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, code := runCCGate(t, append([]string{"__complete"}, tt.args...)...)
+			if code != 0 {
+				t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+			}
+			if got := strings.Split(strings.TrimSpace(stdout), "\n"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestInstallCompletion tests the install location for each shell
+func TestInstallCompletion(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+
+	for shell, target := range map[string]string{
+		"bash": filepath.Join(home, ".local", "share", "bash-completion", "completions", "ccgate"),
+		"zsh":  filepath.Join(home, ".zsh", "completions", "_ccgate"),
+		"fish": filepath.Join(home, "xdg-config", "fish", "completions", "ccgate.fish"),
+	} {
+		if err := installCompletion(shell); err != nil {
+			t.Fatalf("%s: expected no error, got %v", shell, err)
+		}
+		data, err := os.ReadFile(target)
+		if err != nil || !strings.Contains(string(data), "ccgate") {
+			t.Errorf("%s: expected completion script at %s, got %v", shell, target, err)
+		}
+	}
+
+	if err := installCompletion("tcsh"); err == nil {
+		t.Error("Expected error for unsupported shell, got nil")
+	}
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/fatih/color"
 )
@@ -17,11 +20,76 @@ type Platform struct {
 	AnthropicAuthToken  string `json:"ANTHROPIC_AUTH_TOKEN"`
 	AnthropicModel      string `json:"ANTHROPIC_MODEL"`
 	AnthropicSmallModel string `json:"ANTHROPIC_SMALL_FAST_MODEL"`
+
+	// AuthMode 令牌的传递方式：auth_token（默认，ANTHROPIC_AUTH_TOKEN）或 api_key（ANTHROPIC_API_KEY）
+	AuthMode string `json:"auth_mode,omitempty"`
+	// Env 启动时额外设置的环境变量（如厂商要求的超时配置）
+	Env map[string]string `json:"env,omitempty"`
+	// Hooks 仅对该平台生效的启动钩子，在全局钩子之后执行
+	Hooks *Hooks `json:"hooks,omitempty"`
+	// TokenCommand 启动前运行以获取令牌的 shell 命令（标准输出即令牌），设置后可省略 ANTHROPIC_AUTH_TOKEN
+	TokenCommand string `json:"token_command,omitempty"`
+	// TokenTTL 令牌的缓存时长，如 "55m"，为空时每次启动都重新获取
+	TokenTTL string `json:"token_ttl,omitempty"`
+	// EnvPolicy 传递给 claude 的环境变量策略：inherit 或 allowlist，为空时使用全局设置
+	EnvPolicy string `json:"env_policy,omitempty"`
+	// EnvAllowlist allowlist 模式下额外允许的变量，以 * 结尾表示前缀匹配
+	EnvAllowlist []string `json:"env_allowlist,omitempty"`
+	// Fallback 备用平台名称，启动前健康检查失败时按顺序切换到第一个健康的平台
+	Fallback []string `json:"fallback,omitempty"`
+	// MaxSession 会话的最长时长，如 "2h"；IdleTimeout 无输入输出的最长时间。设置后以托管模式启动
+	MaxSession  string `json:"max_session,omitempty"`
+	IdleTimeout string `json:"idle_timeout,omitempty"`
+}
+
+// 令牌传递方式
+const (
+	authModeToken  = "auth_token"
+	authModeAPIKey = "api_key"
+)
+
+// tokenEnvKey 返回平台令牌对应的环境变量名
+func (p *Platform) tokenEnvKey() string {
+	if p.AuthMode == authModeAPIKey {
+		return "ANTHROPIC_API_KEY"
+	}
+	return "ANTHROPIC_AUTH_TOKEN"
+}
+
+// staleTokenEnvKey 返回平台不使用的令牌变量名，启动时从继承的环境中去除，
+// 避免 claude 同时收到两个（可能属于其他厂商的）令牌
+func (p *Platform) staleTokenEnvKey() string {
+	if p.AuthMode == authModeAPIKey {
+		return "ANTHROPIC_AUTH_TOKEN"
+	}
+	return "ANTHROPIC_API_KEY"
+}
+
+// isSecretEnvKey 判断 env 中的变量名是否像机密（包含 KEY、TOKEN 或 SECRET）
+// 录制脱敏和导出 --redact 使用同一规则
+func isSecretEnvKey(key string) bool {
+	upper := strings.ToUpper(key)
+	return strings.Contains(upper, "KEY") || strings.Contains(upper, "TOKEN") || strings.Contains(upper, "SECRET")
 }
 
 // Config 表示配置文件结构
 type Config struct {
 	Platforms []Platform `json:"platforms"`
+
+	// Default 全局默认平台（ccgate use 设置）
+	Default string `json:"default,omitempty"`
+	// Directories 目录绑定，键为绝对路径，值为平台名称（ccgate use --local 设置）
+	Directories map[string]string `json:"directories,omitempty"`
+	// Rules 按工作目录、git 远程地址或分支选择平台的规则，优先级低于目录绑定
+	Rules []Rule `json:"rules,omitempty"`
+	// Supervise 以托管子进程方式启动 claude（默认使用 exec 进程替换）
+	Supervise bool `json:"supervise,omitempty"`
+	// Hooks 对所有平台生效的启动钩子
+	Hooks *Hooks `json:"hooks,omitempty"`
+	// EnvPolicy 默认的环境变量策略：inherit（默认）或 allowlist
+	EnvPolicy string `json:"env_policy,omitempty"`
+	// EnvAllowlist 对所有平台生效的额外允许变量
+	EnvAllowlist []string `json:"env_allowlist,omitempty"`
 }
 
 // Validate 验证平台配置是否有效
@@ -32,12 +100,37 @@ func (p *Platform) Validate() error {
 	if p.AnthropicBaseURL == "" {
 		return fmt.Errorf("平台 %s 缺少 ANTHROPIC_BASE_URL", p.Name)
 	}
-	if p.AnthropicAuthToken == "" {
-		return fmt.Errorf("平台 %s 缺少 ANTHROPIC_AUTH_TOKEN", p.Name)
+	if p.AnthropicAuthToken == "" && p.TokenCommand == "" {
+		return fmt.Errorf("平台 %s 缺少 ANTHROPIC_AUTH_TOKEN（或 token_command）", p.Name)
+	}
+	if p.TokenTTL != "" {
+		if d, err := time.ParseDuration(p.TokenTTL); err != nil || d <= 0 {
+			return fmt.Errorf("平台 %s 的 token_ttl 无效: %s（示例: 55m、1h）", p.Name, p.TokenTTL)
+		}
 	}
 	if p.AnthropicModel == "" {
 		return fmt.Errorf("平台 %s 缺少 ANTHROPIC_MODEL", p.Name)
 	}
+	if p.AuthMode != "" && p.AuthMode != authModeToken && p.AuthMode != authModeAPIKey {
+		return fmt.Errorf("平台 %s 的 auth_mode 无效: %s（可选 %s、%s）", p.Name, p.AuthMode, authModeToken, authModeAPIKey)
+	}
+	if err := p.Hooks.Validate(); err != nil {
+		return fmt.Errorf("平台 %s 的 %w", p.Name, err)
+	}
+	if err := validateEnvPolicy(p.EnvPolicy); err != nil {
+		return fmt.Errorf("平台 %s 的 %w", p.Name, err)
+	}
+	if err := validateLimitDuration("max_session", p.MaxSession); err != nil {
+		return fmt.Errorf("平台 %s 的 %w", p.Name, err)
+	}
+	if err := validateLimitDuration("idle_timeout", p.IdleTimeout); err != nil {
+		return fmt.Errorf("平台 %s 的 %w", p.Name, err)
+	}
+	for _, name := range p.Fallback {
+		if name == p.Name {
+			return fmt.Errorf("平台 %s 的 fallback 不能包含自身", p.Name)
+		}
+	}
 	return nil
 }
 
@@ -51,6 +144,42 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("平台 %d: %w", i+1, err)
 		}
 	}
+	return c.validateReferences()
+}
+
+// validateReferences 校验备用平台和规则引用的平台以及全局钩子、env_policy，不包括各平台自身的校验
+func (c *Config) validateReferences() error {
+	for _, platform := range c.Platforms {
+		for _, name := range platform.Fallback {
+			if _, err := findPlatformByName(c.Platforms, name); err != nil {
+				return fmt.Errorf("平台 %s 的备用平台 %s 不存在", platform.Name, name)
+			}
+		}
+	}
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("规则 %d: %w", i+1, err)
+		}
+		if _, err := findPlatformByName(c.Platforms, rule.Platform); err != nil {
+			return fmt.Errorf("规则 %d: 平台 %s 不存在", i+1, rule.Platform)
+		}
+	}
+	if err := c.Hooks.Validate(); err != nil {
+		return fmt.Errorf("全局 %w", err)
+	}
+	if err := validateEnvPolicy(c.EnvPolicy); err != nil {
+		return fmt.Errorf("全局 %w", err)
+	}
+	return nil
+}
+
+// checkConfig 启动前校验整个配置，包括备用平台、规则、全局钩子和 env_policy
+// loadConfig 本身不做校验，使 add、delete、doctor 等命令仍能用于修复无效的配置
+func checkConfig(config *Config) error {
+	if err := config.Validate(); err != nil {
+		return NewConfigError(fmt.Sprintf("配置无效: %v", err), "运行 'ccgate doctor' 检查配置，修改后重试")
+	}
 	return nil
 }
 
@@ -114,6 +243,13 @@ func saveConfig(config *Config, configPath string) error {
 		return fmt.Errorf("序列化配置失败: %w", err)
 	}
 
+	// 覆盖前备份旧配置，供 ccgate config diff 比较
+	if old, err := os.ReadFile(configPath); err == nil && !bytes.Equal(old, data) {
+		if err := os.WriteFile(configBackupPath(configPath), old, 0o600); err != nil {
+			return fmt.Errorf("备份配置文件失败: %w", err)
+		}
+	}
+
 	if err := os.WriteFile(configPath, data, 0o644); err != nil {
 		return fmt.Errorf("写入配置文件 %s 失败: %w", configPath, err)
 	}
@@ -122,6 +258,14 @@ func saveConfig(config *Config, configPath string) error {
 	return nil
 }
 
+// configBackupPath 返回配置文件的备份路径
+func configBackupPath(configPath string) string {
+	if configPath == "" {
+		configPath = getConfigPath()
+	}
+	return configPath + ".bak"
+}
+
 // findPlatformByName 通过名称查找平台
 func findPlatformByName(platforms []Platform, name string) (*Platform, error) {
 	for i := range platforms {
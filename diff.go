@@ -0,0 +1,436 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var configAgainst string
+
+// diff 子命令
+var diffCmd = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "比较两个平台的配置",
+	Long: `逐项比较两个平台的配置。令牌以及 env 中名称包含 KEY、TOKEN、SECRET 的值
+以指纹（sha256 前缀）显示，可以判断两个平台是否使用同一个令牌而不暴露令牌本身。
+
+示例:
+  ccgate diff prod staging
+  ccgate diff prod staging --output json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+		a, err := findPlatformByName(config.Platforms, args[0])
+		if err != nil {
+			return unknownPlatformError(config.Platforms, args[0], err)
+		}
+		b, err := findPlatformByName(config.Platforms, args[1])
+		if err != nil {
+			return unknownPlatformError(config.Platforms, args[1], err)
+		}
+
+		fields := diffPlatforms(a, b)
+		if structuredOutput() {
+			return writeStructured(struct {
+				A      string      `json:"a" yaml:"a"`
+				B      string      `json:"b" yaml:"b"`
+				Fields []fieldDiff `json:"fields" yaml:"fields"`
+			}{a.Name, b.Name, fields})
+		}
+		printPlatformDiff(a.Name, b.Name, fields)
+		return nil
+	},
+}
+
+// config 子命令组
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "配置文件相关操作",
+	Args:  cobra.NoArgs,
+}
+
+// config diff 子命令
+var configDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "比较当前配置与备份或其他配置文件",
+	Long: `显示从对照配置到当前配置的变化：新增、删除和修改的平台，
+默认平台以及目录绑定的变化。
+
+每次保存配置时，ccgate 会将旧配置备份为 config.json.bak。
+--against backup（默认）与该备份比较，也可以指定其他配置文件。
+
+示例:
+  ccgate config diff
+  ccgate config diff --against ~/dotfiles/ccgate.json --output json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		current, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		againstPath := configAgainst
+		if againstPath == "backup" {
+			againstPath = configBackupPath(cfgFile)
+		}
+		if _, err := os.Stat(againstPath); err != nil {
+			return NewConfigError(
+				fmt.Sprintf("无法读取对照配置 %s: %v", againstPath, err),
+				"保存过一次配置后才会生成备份，或使用 --against <file> 指定其他配置文件")
+		}
+		previous, err := loadConfig(againstPath)
+		if err != nil {
+			return fmt.Errorf("加载对照配置失败: %w", err)
+		}
+
+		changes := diffConfigs(previous, current)
+		if structuredOutput() {
+			return writeStructured(struct {
+				Against string         `json:"against" yaml:"against"`
+				Changes []configChange `json:"changes" yaml:"changes"`
+			}{againstPath, changes})
+		}
+		printConfigDiff(againstPath, changes)
+		return nil
+	},
+}
+
+func init() {
+	configDiffCmd.Flags().StringVar(&configAgainst, "against", "backup", "对照的配置：backup 或配置文件路径")
+	configCmd.AddCommand(configDiffCmd)
+}
+
+// fieldDiff 单个配置项的比较结果
+type fieldDiff struct {
+	Field string `json:"field" yaml:"field"`
+	A     string `json:"a" yaml:"a"`
+	B     string `json:"b" yaml:"b"`
+	Equal bool   `json:"equal" yaml:"equal"`
+}
+
+// configChange 配置的一处变化
+type configChange struct {
+	// Kind added、removed 或 changed
+	Kind string `json:"kind" yaml:"kind"`
+	// Scope platform、default、directory、setting（全局设置）或 rule
+	Scope  string      `json:"scope" yaml:"scope"`
+	Name   string      `json:"name,omitempty" yaml:"name,omitempty"`
+	Old    string      `json:"old,omitempty" yaml:"old,omitempty"`
+	New    string      `json:"new,omitempty" yaml:"new,omitempty"`
+	Fields []fieldDiff `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// tokenFingerprint 返回令牌的指纹，用于比较而不暴露令牌
+func tokenFingerprint(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// platformFields 返回用于比较的平台配置项（令牌和机密 env 值替换为指纹）
+func platformFields(p *Platform) map[string]string {
+	fields := map[string]string{
+		"vendor":                     p.Vendor,
+		"ANTHROPIC_BASE_URL":         p.AnthropicBaseURL,
+		"ANTHROPIC_AUTH_TOKEN":       tokenFingerprint(p.AnthropicAuthToken),
+		"ANTHROPIC_MODEL":            p.AnthropicModel,
+		"ANTHROPIC_SMALL_FAST_MODEL": p.AnthropicSmallModel,
+		"auth_mode":                  p.AuthMode,
+		"token_command":              p.TokenCommand,
+		"token_ttl":                  p.TokenTTL,
+		"env_policy":                 p.EnvPolicy,
+		"env_allowlist":              strings.Join(p.EnvAllowlist, ", "),
+		"fallback":                   strings.Join(p.Fallback, ", "),
+		"max_session":                p.MaxSession,
+		"idle_timeout":               p.IdleTimeout,
+	}
+	addHookFields(fields, p.Hooks)
+	for key, value := range p.Env {
+		if isSecretEnvKey(key) {
+			value = tokenFingerprint(value)
+		}
+		fields["env."+key] = value
+	}
+	return fields
+}
+
+// addHookFields 将钩子配置加入比较项，多个命令以 "; " 连接
+func addHookFields(fields map[string]string, h *Hooks) {
+	if h == nil {
+		return
+	}
+	fields["hooks.pre_launch"] = strings.Join(h.PreLaunch, "; ")
+	fields["hooks.post_launch"] = strings.Join(h.PostLaunch, "; ")
+	fields["hooks.timeout"] = h.Timeout
+}
+
+// configFields 返回用于比较的全局设置
+func configFields(c *Config) map[string]string {
+	fields := map[string]string{
+		"env_policy":    c.EnvPolicy,
+		"env_allowlist": strings.Join(c.EnvAllowlist, ", "),
+	}
+	if c.Supervise {
+		fields["supervise"] = "true"
+	}
+	addHookFields(fields, c.Hooks)
+	return fields
+}
+
+// configFieldOrder 全局设置的输出顺序
+var configFieldOrder = []string{
+	"supervise",
+	"hooks.pre_launch",
+	"hooks.post_launch",
+	"hooks.timeout",
+	"env_policy",
+	"env_allowlist",
+}
+
+// ruleValue 返回第 i 条规则的比较值，不存在时为空
+func ruleValue(rules []Rule, i int) string {
+	if i >= len(rules) {
+		return ""
+	}
+	return rules[i].String() + " platform=" + rules[i].Platform
+}
+
+// platformFieldOrder 固定配置项的输出顺序，env.* 按字母序排在最后
+var platformFieldOrder = []string{
+	"vendor",
+	"ANTHROPIC_BASE_URL",
+	"ANTHROPIC_AUTH_TOKEN",
+	"ANTHROPIC_MODEL",
+	"ANTHROPIC_SMALL_FAST_MODEL",
+	"auth_mode",
+	"token_command",
+	"token_ttl",
+	"hooks.pre_launch",
+	"hooks.post_launch",
+	"hooks.timeout",
+	"env_policy",
+	"env_allowlist",
+	"fallback",
+	"max_session",
+	"idle_timeout",
+}
+
+// diffPlatforms 逐项比较两个平台，跳过两边都为空的配置项
+func diffPlatforms(a, b *Platform) []fieldDiff {
+	fa, fb := platformFields(a), platformFields(b)
+
+	var envKeys []string
+	seen := make(map[string]bool)
+	for _, fields := range []map[string]string{fa, fb} {
+		for key := range fields {
+			if strings.HasPrefix(key, "env.") && !seen[key] {
+				seen[key] = true
+				envKeys = append(envKeys, key)
+			}
+		}
+	}
+	sort.Strings(envKeys)
+
+	var out []fieldDiff
+	for _, key := range append(append([]string(nil), platformFieldOrder...), envKeys...) {
+		if fa[key] == "" && fb[key] == "" {
+			continue
+		}
+		out = append(out, fieldDiff{Field: key, A: fa[key], B: fb[key], Equal: fa[key] == fb[key]})
+	}
+	return out
+}
+
+// diffConfigs 计算从 previous 到 current 的配置变化
+func diffConfigs(previous, current *Config) []configChange {
+	var changes []configChange
+
+	for _, p := range current.Platforms {
+		old, err := findPlatformByName(previous.Platforms, p.Name)
+		if err != nil {
+			changes = append(changes, configChange{Kind: "added", Scope: "platform", Name: p.Name})
+			continue
+		}
+		var changed []fieldDiff
+		for _, f := range diffPlatforms(old, &p) {
+			if !f.Equal {
+				changed = append(changed, f)
+			}
+		}
+		if len(changed) > 0 {
+			changes = append(changes, configChange{Kind: "changed", Scope: "platform", Name: p.Name, Fields: changed})
+		}
+	}
+	for _, p := range previous.Platforms {
+		if _, err := findPlatformByName(current.Platforms, p.Name); err != nil {
+			changes = append(changes, configChange{Kind: "removed", Scope: "platform", Name: p.Name})
+		}
+	}
+
+	if c, ok := valueChange("default", "", previous.Default, current.Default); ok {
+		changes = append(changes, c)
+	}
+
+	prevFields, curFields := configFields(previous), configFields(current)
+	for _, key := range configFieldOrder {
+		if c, ok := valueChange("setting", key, prevFields[key], curFields[key]); ok {
+			changes = append(changes, c)
+		}
+	}
+
+	dirs := make(map[string]bool)
+	for dir := range previous.Directories {
+		dirs[dir] = true
+	}
+	for dir := range current.Directories {
+		dirs[dir] = true
+	}
+	sortedDirs := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Strings(sortedDirs)
+	for _, dir := range sortedDirs {
+		old, hadOld := previous.Directories[dir]
+		cur, hasCur := current.Directories[dir]
+		switch {
+		case !hadOld:
+			changes = append(changes, configChange{Kind: "added", Scope: "directory", Name: dir, New: cur})
+		case !hasCur:
+			changes = append(changes, configChange{Kind: "removed", Scope: "directory", Name: dir, Old: old})
+		case old != cur:
+			changes = append(changes, configChange{Kind: "changed", Scope: "directory", Name: dir, Old: old, New: cur})
+		}
+	}
+
+	// 规则按顺序匹配，因此按位置比较
+	for i := 0; i < max(len(previous.Rules), len(current.Rules)); i++ {
+		name := fmt.Sprintf("#%d", i+1)
+		if c, ok := valueChange("rule", name, ruleValue(previous.Rules, i), ruleValue(current.Rules, i)); ok {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+// valueChange 比较单个值，空值表示未设置
+func valueChange(scope, name, old, cur string) (configChange, bool) {
+	c := configChange{Scope: scope, Name: name, Old: old, New: cur}
+	switch {
+	case old == cur:
+		return c, false
+	case old == "":
+		c.Kind = "added"
+	case cur == "":
+		c.Kind = "removed"
+	default:
+		c.Kind = "changed"
+	}
+	return c, true
+}
+
+// printPlatformDiff 以表格形式输出两个平台的比较结果，不同的配置项高亮显示
+func printPlatformDiff(a, b string, fields []fieldDiff) {
+	theme := DefaultTheme()
+
+	tableData := pterm.TableData{{"", a, b}}
+	same := 0
+	for _, f := range fields {
+		va, vb := orDash(f.A), orDash(f.B)
+		if f.Equal {
+			same++
+			tableData = append(tableData, []string{
+				theme.Colors.Muted.Sprint(f.Field), theme.Colors.Muted.Sprint(va), theme.Colors.Muted.Sprint(vb)})
+			continue
+		}
+		tableData = append(tableData, []string{
+			theme.Colors.Primary.Sprint(f.Field), theme.Colors.Error.Sprint(va), theme.Colors.Success.Sprint(vb)})
+	}
+
+	pterm.DefaultTable.WithHasHeader(true).
+		WithBoxed(true).
+		WithData(tableData).
+		Render()
+	Spacer(theme.Spacing.XS, theme)
+
+	if same == len(fields) {
+		DisplayInfo("两个平台的配置完全相同", theme)
+	} else {
+		DisplayInfo(fmt.Sprintf("%d 项不同，%d 项相同", len(fields)-same, same), theme)
+	}
+}
+
+// printConfigDiff 输出配置变化，+ 新增、- 删除、~ 修改
+func printConfigDiff(against string, changes []configChange) {
+	theme := DefaultTheme()
+
+	if len(changes) == 0 {
+		DisplayInfo(fmt.Sprintf("当前配置与 %s 相同", against), theme)
+		return
+	}
+
+	pterm.Info.Printf("%s\n", theme.Colors.Primary.Sprintf("相对于 %s 的变化 (%d)", against, len(changes)))
+	Spacer(theme.Spacing.XS, theme)
+
+	for _, c := range changes {
+		var line string
+		switch c.Scope {
+		case "platform":
+			line = fmt.Sprintf("平台 %s", c.Name)
+		case "default":
+			line = fmt.Sprintf("默认平台: %s", changeValue(c))
+		case "directory":
+			line = fmt.Sprintf("目录绑定 %s: %s", c.Name, changeValue(c))
+		case "setting":
+			line = fmt.Sprintf("全局设置 %s: %s", c.Name, changeValue(c))
+		case "rule":
+			line = fmt.Sprintf("规则 %s: %s", c.Name, changeValue(c))
+		}
+
+		switch c.Kind {
+		case "added":
+			pterm.Println(theme.Colors.Success.Sprint("+ " + line))
+		case "removed":
+			pterm.Println(theme.Colors.Error.Sprint("- " + line))
+		default:
+			pterm.Println(theme.Colors.Warning.Sprint("~ " + line))
+		}
+		for _, f := range c.Fields {
+			pterm.Printf("    %s: %s → %s\n", f.Field, orDash(f.A), orDash(f.B))
+		}
+	}
+	Spacer(theme.Spacing.XS, theme)
+}
+
+// changeValue 返回变化前后的值
+func changeValue(c configChange) string {
+	switch c.Kind {
+	case "added":
+		return c.New
+	case "removed":
+		return c.Old
+	default:
+		return c.Old + " → " + c.New
+	}
+}
+
+// orDash 空值显示为 -
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
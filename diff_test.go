@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDiffPlatforms tests field comparison with token fingerprints
+func TestDiffPlatforms(t *testing.T) {
+	a := &Platform{Name: "a", AnthropicBaseURL: "https://a.example.com", AnthropicAuthToken: "secret-token-1", AnthropicModel: "m1"}
+	b := &Platform{Name: "b", AnthropicBaseURL: "https://a.example.com", AnthropicAuthToken: "secret-token-2", AnthropicModel: "m1",
+		Env: map[string]string{"API_TIMEOUT_MS": "600000", "AWS_SECRET_ACCESS_KEY": "secret-aws"}}
+
+	fields := diffPlatforms(a, b)
+	got := make(map[string]fieldDiff)
+	for _, f := range fields {
+		got[f.Field] = f
+		if strings.Contains(f.A, "secret") || strings.Contains(f.B, "secret") {
+			t.Errorf("Expected token to be fingerprinted, got %+v", f)
+		}
+	}
+	if !got["ANTHROPIC_BASE_URL"].Equal || !got["ANTHROPIC_MODEL"].Equal {
+		t.Errorf("Expected base URL and model to be equal, got %+v", fields)
+	}
+	if got["ANTHROPIC_AUTH_TOKEN"].Equal {
+		t.Error("Expected different tokens to have different fingerprints")
+	}
+	if f, ok := got["env.API_TIMEOUT_MS"]; !ok || f.Equal || f.A != "" || f.B != "600000" {
+		t.Errorf("Expected env difference, got %+v", f)
+	}
+	if f := got["env.AWS_SECRET_ACCESS_KEY"]; f.B != tokenFingerprint("secret-aws") {
+		t.Errorf("Expected secret-named env value to be fingerprinted, got %+v", f)
+	}
+	if _, ok := got["ANTHROPIC_SMALL_FAST_MODEL"]; ok {
+		t.Error("Expected fields empty on both sides to be skipped")
+	}
+	if tokenFingerprint("x") != tokenFingerprint("x") || tokenFingerprint("") != "" {
+		t.Error("Expected stable fingerprints and empty fingerprint for empty token")
+	}
+}
+
+// TestDiffConfigs tests platform, default and directory changes between configs
+func TestDiffConfigs(t *testing.T) {
+	previous := &Config{
+		Platforms:   []Platform{{Name: "prod", AnthropicModel: "m1"}, {Name: "old"}},
+		Default:     "prod",
+		Directories: map[string]string{"/a": "prod", "/b": "old"},
+	}
+	current := &Config{
+		Platforms:   []Platform{{Name: "prod", AnthropicModel: "m2"}, {Name: "kimi"}},
+		Default:     "kimi",
+		Directories: map[string]string{"/a": "kimi"},
+	}
+
+	var summary []string
+	for _, c := range diffConfigs(previous, current) {
+		summary = append(summary, c.Kind+" "+c.Scope+" "+c.Name)
+	}
+	want := []string{
+		"changed platform prod",
+		"added platform kimi",
+		"removed platform old",
+		"changed default ",
+		"changed directory /a",
+		"removed directory /b",
+	}
+	if strings.Join(summary, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected changes %v, got %v", want, summary)
+	}
+}
+
+// TestDiffPlatformsLaterFields tests that every platform field is compared, not just the connection settings
+func TestDiffPlatformsLaterFields(t *testing.T) {
+	base := Platform{Name: "a", AnthropicBaseURL: "https://a", AnthropicAuthToken: "t", AnthropicModel: "m"}
+	tests := []struct {
+		field  string
+		modify func(p *Platform)
+	}{
+		{"hooks.pre_launch", func(p *Platform) { p.Hooks = &Hooks{PreLaunch: []string{"vpn up"}} }},
+		{"hooks.post_launch", func(p *Platform) { p.Hooks = &Hooks{PostLaunch: []string{"vpn down"}} }},
+		{"hooks.timeout", func(p *Platform) { p.Hooks = &Hooks{Timeout: "5s"} }},
+		{"token_command", func(p *Platform) { p.TokenCommand = "vault read token" }},
+		{"token_ttl", func(p *Platform) { p.TokenTTL = "55m" }},
+		{"env_policy", func(p *Platform) { p.EnvPolicy = envPolicyAllowlist }},
+		{"env_allowlist", func(p *Platform) { p.EnvAllowlist = []string{"AWS_*", "HOME"} }},
+		{"fallback", func(p *Platform) { p.Fallback = []string{"b", "c"} }},
+		{"max_session", func(p *Platform) { p.MaxSession = "2h" }},
+		{"idle_timeout", func(p *Platform) { p.IdleTimeout = "30m" }},
+	}
+	for _, tt := range tests {
+		changed := base
+		tt.modify(&changed)
+
+		var diffs []string
+		for _, f := range diffPlatforms(&base, &changed) {
+			if !f.Equal {
+				diffs = append(diffs, f.Field)
+			}
+		}
+		if len(diffs) != 1 || diffs[0] != tt.field {
+			t.Errorf("%s: expected exactly one difference, got %v", tt.field, diffs)
+		}
+
+		changes := diffConfigs(&Config{Platforms: []Platform{base}}, &Config{Platforms: []Platform{changed}})
+		if len(changes) != 1 || changes[0].Name != "a" {
+			t.Errorf("%s: expected config diff to report platform a, got %+v", tt.field, changes)
+		}
+	}
+}
+
+// TestDiffConfigsSettingsAndRules tests global settings and rules in config diff
+func TestDiffConfigsSettingsAndRules(t *testing.T) {
+	previous := &Config{
+		Hooks:     &Hooks{PreLaunch: []string{"echo start"}},
+		EnvPolicy: envPolicyInherit,
+		Rules: []Rule{
+			{Dir: "~/work/clientA", Platform: "a"},
+			{Remote: "*clientB*", Platform: "b"},
+		},
+	}
+	current := &Config{
+		Supervise:    true,
+		EnvPolicy:    envPolicyAllowlist,
+		EnvAllowlist: []string{"AWS_*"},
+		Rules:        []Rule{{Dir: "~/work/clientA", Platform: "c"}},
+	}
+
+	var summary []string
+	for _, c := range diffConfigs(previous, current) {
+		summary = append(summary, c.Kind+" "+c.Scope+" "+c.Name+" "+changeValue(c))
+	}
+	want := []string{
+		"added setting supervise true",
+		"removed setting hooks.pre_launch echo start",
+		"changed setting env_policy inherit → allowlist",
+		"added setting env_allowlist AWS_*",
+		"changed rule #1 dir=~/work/clientA platform=a → dir=~/work/clientA platform=c",
+		"removed rule #2 remote=*clientB* platform=b",
+	}
+	if strings.Join(summary, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected changes:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(summary, "\n"))
+	}
+	if len(diffConfigs(current, current)) != 0 {
+		t.Error("Expected no changes for identical configs")
+	}
+}
+
+// TestSaveConfigBackup tests that saving keeps the previous config as a backup
+func TestSaveConfigBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := saveConfig(&Config{Platforms: []Platform{{Name: "first"}}}, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(configBackupPath(path)); !os.IsNotExist(err) {
+		t.Error("Expected no backup after the first save")
+	}
+	if err := saveConfig(&Config{Platforms: []Platform{{Name: "second"}}}, path); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := loadConfig(configBackupPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backup.Platforms) != 1 || backup.Platforms[0].Name != "first" {
+		t.Errorf("Expected backup of the previous config, got %+v", backup.Platforms)
+	}
+}
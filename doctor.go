@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// doctor 子命令
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "诊断常见的启动问题",
+	Long: `检查影响 claude 启动的常见问题，并给出修复建议：
+
+  - claude 可执行文件及其版本
+  - 配置文件权限、格式和内容
+  - 当前 shell 中冲突的 ANTHROPIC_* 环境变量
+  - 交互式选择所需的 TTY
+  - 每个平台的配置校验
+  - 备用平台、规则、全局钩子和 env_policy 的校验（与启动前的校验一致）
+
+使用 --output json|yaml 输出机器可读的结果，便于附加到问题反馈中。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		findings := runDoctor(cfgFile)
+
+		if structuredOutput() {
+			if err := printDoctorStructured(findings); err != nil {
+				return err
+			}
+		} else {
+			printDoctorReport(findings)
+		}
+
+		// 存在错误时以非零状态退出，便于脚本判断
+		for _, f := range findings {
+			if f.Status == doctorStatusError {
+				os.Exit(1)
+			}
+		}
+		return nil
+	},
+}
+
+// 诊断结果状态
+const (
+	doctorStatusOK    = "ok"
+	doctorStatusInfo  = "info"
+	doctorStatusWarn  = "warn"
+	doctorStatusError = "error"
+)
+
+// doctorFinding 单项诊断结果，问题描述和恢复建议复用 UIError
+type doctorFinding struct {
+	Check  string
+	Status string
+	Detail string
+	Err    *UIError
+}
+
+// doctorFindingOutput 诊断结果的结构化表示
+type doctorFindingOutput struct {
+	Check    string `json:"check" yaml:"check"`
+	Status   string `json:"status" yaml:"status"`
+	Message  string `json:"message" yaml:"message"`
+	Recovery string `json:"recovery,omitempty" yaml:"recovery,omitempty"`
+}
+
+// okFinding 创建通过的诊断结果
+func okFinding(check, detail string) doctorFinding {
+	return doctorFinding{Check: check, Status: doctorStatusOK, Detail: detail}
+}
+
+// problemFinding 创建带恢复建议的诊断结果
+func problemFinding(check string, errType ErrorType, severity Severity, message, recovery string) doctorFinding {
+	status := doctorStatusError
+	switch severity {
+	case SeverityInfo:
+		status = doctorStatusInfo
+	case SeverityWarning:
+		status = doctorStatusWarn
+	}
+	return doctorFinding{
+		Check:  check,
+		Status: status,
+		Err: &UIError{
+			Type:      errType,
+			Message:   message,
+			Recovery:  recovery,
+			Severity:  severity,
+			Timestamp: time.Now(),
+		},
+	}
+}
+
+// runDoctor 执行全部诊断
+func runDoctor(configPath string) []doctorFinding {
+	var findings []doctorFinding
+	findings = append(findings, checkClaudeBinary())
+
+	config, configFindings := checkConfigFile(configPath)
+	findings = append(findings, configFindings...)
+
+	var platforms []Platform
+	if config != nil {
+		platforms = config.Platforms
+	}
+	findings = append(findings, checkAnthropicEnv(os.Environ(), platforms)...)
+	findings = append(findings, checkTTY(config))
+
+	if config != nil {
+		findings = append(findings, checkPlatforms(config)...)
+		findings = append(findings, checkConfigReferences(config))
+	}
+	return findings
+}
+
+// checkClaudeBinary 检查 claude 是否在 PATH 中并获取版本
+func checkClaudeBinary() doctorFinding {
+	const check = "claude"
+
+	path, err := exec.LookPath("claude")
+	if err != nil {
+		return problemFinding(check, ErrorTypeSystem, SeverityError,
+			"找不到 claude 可执行文件",
+			"安装 Claude Code（https://claude.ai/download）并确保其所在目录在 PATH 中")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return problemFinding(check, ErrorTypeSystem, SeverityWarning,
+			fmt.Sprintf("无法获取 claude 版本（%s）: %v", path, err),
+			fmt.Sprintf("尝试直接运行 '%s --version' 检查安装是否完整", path))
+	}
+
+	return okFinding(check, fmt.Sprintf("%s（%s）", strings.TrimSpace(string(out)), path))
+}
+
+// checkConfigFile 检查配置文件的权限、格式和内容
+// 配置可以解析时返回加载后的配置供后续检查使用
+func checkConfigFile(configPath string) (*Config, []doctorFinding) {
+	const check = "config"
+
+	if configPath == "" {
+		configPath = getConfigPath()
+	}
+
+	info, err := os.Stat(configPath)
+	if os.IsNotExist(err) {
+		return nil, []doctorFinding{problemFinding(check, ErrorTypeConfig, SeverityWarning,
+			fmt.Sprintf("配置文件不存在: %s", configPath),
+			"运行 'ccgate add' 添加第一个平台")}
+	}
+	if err != nil {
+		return nil, []doctorFinding{problemFinding(check, ErrorTypeConfig, SeverityError,
+			fmt.Sprintf("无法访问配置文件 %s: %v", configPath, err),
+			"检查配置文件及其所在目录的权限")}
+	}
+
+	var findings []doctorFinding
+
+	// 配置中包含令牌，不应被其他用户读取
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		findings = append(findings, problemFinding(check, ErrorTypeConfig, SeverityWarning,
+			fmt.Sprintf("配置文件权限过于宽松（%04o），其中的令牌可能被其他用户读取", info.Mode().Perm()),
+			fmt.Sprintf("运行 'chmod 600 %s'", configPath)))
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		findings = append(findings, problemFinding(check, ErrorTypeConfig, SeverityError,
+			fmt.Sprintf("配置文件无法解析: %v", err),
+			"修复 JSON 格式错误，或删除该文件后重新运行 'ccgate add'"))
+		return nil, findings
+	}
+
+	// 平台名称应唯一，否则只有第一个会被使用
+	seen := make(map[string]bool)
+	for _, p := range config.Platforms {
+		if seen[p.Name] {
+			findings = append(findings, problemFinding(check, ErrorTypeConfig, SeverityWarning,
+				fmt.Sprintf("平台名称 '%s' 重复，只有第一个会被使用", p.Name),
+				"编辑配置文件，为重复的平台改名或删除多余的条目"))
+		}
+		seen[p.Name] = true
+	}
+
+	if config.Default != "" && !seen[config.Default] {
+		findings = append(findings, problemFinding(check, ErrorTypeConfig, SeverityWarning,
+			fmt.Sprintf("默认平台 '%s' 不存在", config.Default),
+			"运行 'ccgate use <name>' 重新设置，或 'ccgate use --unset' 清除"))
+	}
+	dirs := make([]string, 0, len(config.Directories))
+	for dir := range config.Directories {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if name := config.Directories[dir]; !seen[name] {
+			findings = append(findings, problemFinding(check, ErrorTypeConfig, SeverityWarning,
+				fmt.Sprintf("目录 %s 绑定的平台 '%s' 不存在", dir, name),
+				"在该目录下运行 'ccgate use <name> --local' 重新绑定，或 'ccgate use --unset --local' 清除"))
+		}
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, okFinding(check,
+			fmt.Sprintf("%s（%d 个平台）", configPath, len(config.Platforms))))
+	}
+	return config, findings
+}
+
+// checkAnthropicEnv 检查当前 shell 中已存在的 ANTHROPIC_* 环境变量
+// 与启动时使用同一份规则（replacedEnvKeys）：只有对所有平台都会被覆盖或去除的变量才视为无影响
+func checkAnthropicEnv(environ []string, platforms []Platform) []doctorFinding {
+	const check = "env"
+
+	if len(platforms) == 0 {
+		platforms = []Platform{{}}
+	}
+	overridden := replacedEnvKeys(&platforms[0])
+	for i := range platforms[1:] {
+		keys := replacedEnvKeys(&platforms[i+1])
+		for key := range overridden {
+			if !keys[key] {
+				delete(overridden, key)
+			}
+		}
+	}
+
+	var replaced, leaked []string
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, "ANTHROPIC_") {
+			continue
+		}
+		if overridden[key] {
+			replaced = append(replaced, key)
+		} else {
+			leaked = append(leaked, key)
+		}
+	}
+	sort.Strings(replaced)
+	sort.Strings(leaked)
+
+	var findings []doctorFinding
+	if len(leaked) > 0 {
+		findings = append(findings, problemFinding(check, ErrorTypeConfig, SeverityWarning,
+			fmt.Sprintf("当前 shell 中的 %s 不会被 ccgate 覆盖，可能与平台配置冲突", strings.Join(leaked, ", ")),
+			fmt.Sprintf("如非必要，运行 'unset %s'", strings.Join(leaked, " "))))
+	}
+	if len(replaced) > 0 {
+		findings = append(findings, problemFinding(check, ErrorTypeConfig, SeverityInfo,
+			fmt.Sprintf("当前 shell 中的 %s 会在启动时被平台配置覆盖或去除", strings.Join(replaced, ", ")),
+			"直接运行 claude 时仍会使用这些值，建议从 shell 配置文件中移除"))
+	}
+	if len(findings) == 0 {
+		findings = append(findings, okFinding(check, "没有冲突的 ANTHROPIC_* 环境变量"))
+	}
+	return findings
+}
+
+// checkTTY 检查交互式选择平台所需的终端
+func checkTTY(config *Config) doctorFinding {
+	const check = "tty"
+
+	if term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) {
+		return okFinding(check, "标准输入和输出均为终端，支持交互式选择")
+	}
+
+	severity := SeverityInfo
+	if config != nil && len(config.Platforms) > 1 && config.Default == "" {
+		severity = SeverityWarning
+	}
+	return problemFinding(check, ErrorTypeSystem, severity,
+		"当前环境不是终端，无法交互式选择平台和确认",
+		"使用 -p 指定平台并加上 --yes，或运行 'ccgate use <name>' 设置默认平台")
+}
+
+// checkPlatforms 校验每个平台的配置
+func checkPlatforms(config *Config) []doctorFinding {
+	var findings []doctorFinding
+	for _, p := range config.Platforms {
+		check := "platform:" + p.Name
+		if err := p.Validate(); err != nil {
+			findings = append(findings, problemFinding(check, ErrorTypeValidation, SeverityError,
+				err.Error(),
+				"运行 'ccgate add' 并使用相同名称重新填写该平台"))
+			continue
+		}
+		findings = append(findings, okFinding(check, fmt.Sprintf("%s（%s）", p.AnthropicBaseURL, p.AnthropicModel)))
+	}
+	return findings
+}
+
+// checkConfigReferences 校验备用平台、规则、全局钩子和 env_policy，与启动前的 Config.Validate 一致
+// 各平台自身的问题已由 checkPlatforms 逐个报告，这里不再重复
+func checkConfigReferences(config *Config) doctorFinding {
+	const check = "config"
+
+	if err := config.validateReferences(); err != nil {
+		return problemFinding(check, ErrorTypeConfig, SeverityError,
+			err.Error(),
+			"编辑配置文件修正该设置，或删除引用了不存在平台的条目")
+	}
+	return okFinding(check, fmt.Sprintf("备用平台、规则（%d 条）和全局设置有效", len(config.Rules)))
+}
+
+// printDoctorReport 以可读格式输出诊断结果
+func printDoctorReport(findings []doctorFinding) {
+	theme := DefaultTheme()
+
+	pterm.Info.Printf("%s\n", theme.Colors.Primary.Sprint("🩺 ccgate 环境诊断"))
+	Spacer(theme.Spacing.XS, theme)
+
+	problems := 0
+	for _, f := range findings {
+		var mark string
+		switch f.Status {
+		case doctorStatusOK:
+			mark = theme.Colors.Success.Sprint("✓")
+		case doctorStatusInfo:
+			mark = theme.Colors.Info.Sprint("i")
+		case doctorStatusWarn:
+			mark = theme.Colors.Warning.Sprint("!")
+			problems++
+		default:
+			mark = theme.Colors.Error.Sprint("✗")
+			problems++
+		}
+
+		message := f.Detail
+		if f.Err != nil {
+			message = f.Err.Message
+		}
+		pterm.Printf("%s %s %s\n", mark, theme.Colors.Secondary.Sprintf("[%s]", f.Check), message)
+		if f.Err != nil && f.Err.Recovery != "" {
+			pterm.Printf("    %s %s\n", theme.Colors.Muted.Sprint("建议:"), f.Err.Recovery)
+		}
+	}
+
+	Spacer(theme.Spacing.XS, theme)
+	if problems == 0 {
+		DisplaySuccess("未发现问题", theme)
+	} else {
+		DisplayWarning(fmt.Sprintf("发现 %d 个问题", problems), theme)
+	}
+}
+
+// printDoctorStructured 以 --output 指定的格式输出诊断结果
+func printDoctorStructured(findings []doctorFinding) error {
+	out := make([]doctorFindingOutput, len(findings))
+	for i, f := range findings {
+		out[i] = doctorFindingOutput{Check: f.Check, Status: f.Status, Message: f.Detail}
+		if f.Err != nil {
+			out[i].Message = f.Err.Message
+			out[i].Recovery = f.Err.Recovery
+		}
+	}
+	return writeStructured(out)
+}
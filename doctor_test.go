@@ -0,0 +1,133 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckAnthropicEnv tests detection of conflicting ANTHROPIC_* variables
+func TestCheckAnthropicEnv(t *testing.T) {
+	findings := checkAnthropicEnv([]string{"PATH=/usr/bin", "HOME=/root"}, nil)
+	if len(findings) != 1 || findings[0].Status != doctorStatusOK {
+		t.Errorf("Expected a single ok finding, got %+v", findings)
+	}
+
+	findings = checkAnthropicEnv([]string{
+		"ANTHROPIC_CUSTOM_HEADERS=x-team: a",
+		"ANTHROPIC_BASE_URL=https://api.test.com",
+	}, nil)
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %d", len(findings))
+	}
+	if findings[0].Status != doctorStatusWarn || findings[0].Err == nil || findings[0].Err.Recovery == "" {
+		t.Errorf("Expected a warning with recovery for ANTHROPIC_CUSTOM_HEADERS, got %+v", findings[0])
+	}
+	if findings[1].Status != doctorStatusInfo {
+		t.Errorf("Expected info for overridden ANTHROPIC_BASE_URL, got %+v", findings[1])
+	}
+}
+
+// TestCheckAnthropicEnvMatchesLaunch tests that doctor agrees with composeEnviron about which variables reach claude
+func TestCheckAnthropicEnvMatchesLaunch(t *testing.T) {
+	environ := []string{
+		"ANTHROPIC_API_KEY=sk-old",
+		"ANTHROPIC_AUTH_TOKEN=old",
+		"ANTHROPIC_SMALL_FAST_MODEL=haiku",
+		"ANTHROPIC_CUSTOM_HEADERS=x",
+	}
+	for _, platforms := range [][]Platform{
+		{{Name: "token", AnthropicModel: "m"}},
+		{{Name: "key", AuthMode: authModeAPIKey, AnthropicModel: "m", AnthropicSmallModel: "s"}},
+		{{Name: "token", AnthropicModel: "m", AnthropicSmallModel: "s"}, {Name: "key", AuthMode: authModeAPIKey, AnthropicModel: "m"}},
+	} {
+		// 对任一平台会被继承的变量都应给出警告
+		inherited := make(map[string]bool)
+		for i := range platforms {
+			env := composeEnviron(environ, &platforms[i], envPolicy{Mode: envPolicyInherit})
+			for _, kv := range environ {
+				for _, got := range env {
+					if got == kv {
+						key, _, _ := strings.Cut(kv, "=")
+						inherited[key] = true
+					}
+				}
+			}
+		}
+
+		var warned []string
+		for _, f := range checkAnthropicEnv(environ, platforms) {
+			if f.Status == doctorStatusWarn {
+				warned = append(warned, f.Err.Message)
+			}
+		}
+		for _, kv := range environ {
+			key, _, _ := strings.Cut(kv, "=")
+			if mentioned := strings.Contains(strings.Join(warned, " "), key); mentioned != inherited[key] {
+				t.Errorf("%v: %s inherited=%v but warned=%v (%q)", platforms, key, inherited[key], mentioned, warned)
+			}
+		}
+	}
+}
+
+// TestCheckConfigReferences tests that doctor reports the config-level problems checked before launch
+func TestCheckConfigReferences(t *testing.T) {
+	platform := func(name string, fallback ...string) Platform {
+		return Platform{Name: name, AnthropicBaseURL: "https://x", AnthropicAuthToken: "t", AnthropicModel: "m", Fallback: fallback}
+	}
+
+	tests := []struct {
+		name   string
+		config *Config
+		want   string
+	}{
+		{"valid", &Config{Platforms: []Platform{platform("a", "b"), platform("b")}, Rules: []Rule{{Dir: "~/w", Platform: "a"}}}, ""},
+		{"fallback", &Config{Platforms: []Platform{platform("a", "bb")}}, "备用平台 bb 不存在"},
+		{"rule", &Config{Platforms: []Platform{platform("a")}, Rules: []Rule{{Platform: "a"}}}, "规则 1"},
+		{"rule platform", &Config{Platforms: []Platform{platform("a")}, Rules: []Rule{{Dir: "~/w", Platform: "x"}}}, "平台 x 不存在"},
+		{"hooks", &Config{Platforms: []Platform{platform("a")}, Hooks: &Hooks{Timeout: "soon"}}, "hooks.timeout"},
+		{"env_policy", &Config{Platforms: []Platform{platform("a")}, EnvPolicy: "allowlst"}, "env_policy"},
+	}
+	for _, tt := range tests {
+		f := checkConfigReferences(tt.config)
+		if tt.want == "" {
+			if f.Status != doctorStatusOK || f.Check != "config" {
+				t.Errorf("%s: expected ok config finding, got %+v", tt.name, f)
+			}
+			continue
+		}
+		if f.Status != doctorStatusError || f.Err == nil || !strings.Contains(f.Err.Message, tt.want) {
+			t.Errorf("%s: expected error containing %q, got %+v", tt.name, tt.want, f)
+		}
+	}
+}
+
+// TestRunDoctorConfig tests that runDoctor includes the config finding alongside per-platform findings
+func TestRunDoctorConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := &Config{Platforms: []Platform{
+		{Name: "a", AnthropicBaseURL: "https://x", AnthropicAuthToken: "t", AnthropicModel: "m", Fallback: []string{"missing"}},
+		{Name: "broken"},
+	}}
+	if err := saveConfig(config, path); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := make(map[string][]string)
+	for _, f := range runDoctor(path) {
+		statuses[f.Check] = append(statuses[f.Check], f.Status)
+	}
+	if got := statuses["platform:broken"]; len(got) != 1 || got[0] != doctorStatusError {
+		t.Errorf("Expected error for invalid platform, got %v", got)
+	}
+	if got := statuses["platform:a"]; len(got) != 1 || got[0] != doctorStatusOK {
+		t.Errorf("Expected platform a itself to be valid, got %v", got)
+	}
+	found := false
+	for _, s := range statuses["config"] {
+		found = found || s == doctorStatusError
+	}
+	if !found {
+		t.Errorf("Expected config error for the dangling fallback, got %v", statuses["config"])
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 环境变量策略
+const (
+	// envPolicyInherit 继承 ccgate 的全部环境变量（默认）
+	envPolicyInherit = "inherit"
+	// envPolicyAllowlist 只传递允许列表中的变量和平台自身的变量
+	envPolicyAllowlist = "allowlist"
+)
+
+// defaultEnvAllowlist allowlist 模式下始终传递的变量，以 * 结尾表示前缀匹配
+// 只包含终端、语言、路径和 SSH 代理等运行 claude 及其工具所需的变量
+var defaultEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "PWD", "TMPDIR", "TZ",
+	"TERM", "TERM_PROGRAM", "TERM_PROGRAM_VERSION", "COLORTERM", "COLUMNS", "LINES", "NO_COLOR",
+	"LANG", "LANGUAGE", "LC_*",
+	"SSH_AUTH_SOCK", "DISPLAY", "WAYLAND_DISPLAY", "XDG_*",
+	"EDITOR", "VISUAL", "PAGER",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+	"CCGATE_*",
+}
+
+// validateEnvPolicy 校验 env_policy 的取值
+func validateEnvPolicy(policy string) error {
+	switch policy {
+	case "", envPolicyInherit, envPolicyAllowlist:
+		return nil
+	}
+	return fmt.Errorf("env_policy 无效: %s（可选 %s、%s）", policy, envPolicyInherit, envPolicyAllowlist)
+}
+
+// envPolicy 启动时生效的环境变量策略
+type envPolicy struct {
+	Mode string
+	// Allow 额外允许的变量（全局与平台的 env_allowlist 合并）
+	Allow []string
+}
+
+// resolveEnvPolicy 合并全局与平台的环境变量策略，平台的 env_policy 优先
+// 任一层级的 env_policy 无效时返回错误，避免拼写错误导致全部环境变量被传递
+func resolveEnvPolicy(config *Config, platform *Platform) (envPolicy, error) {
+	if err := validateEnvPolicy(config.EnvPolicy); err != nil {
+		return envPolicy{}, NewConfigError("全局 "+err.Error(), "修改配置中的 env_policy 后重试")
+	}
+	if err := validateEnvPolicy(platform.EnvPolicy); err != nil {
+		return envPolicy{}, NewConfigError(fmt.Sprintf("平台 %s 的 %v", platform.Name, err), "修改该平台的 env_policy 后重试")
+	}
+
+	policy := envPolicy{Mode: envPolicyInherit}
+	if config.EnvPolicy != "" {
+		policy.Mode = config.EnvPolicy
+	}
+	if platform.EnvPolicy != "" {
+		policy.Mode = platform.EnvPolicy
+	}
+	policy.Allow = append(append(policy.Allow, config.EnvAllowlist...), platform.EnvAllowlist...)
+	return policy, nil
+}
+
+// allowed 判断变量名是否在允许列表中
+func (p envPolicy) allowed(key string) bool {
+	for _, list := range [][]string{defaultEnvAllowlist, p.Allow} {
+		for _, pattern := range list {
+			if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+				if strings.HasPrefix(key, prefix) {
+					return true
+				}
+			} else if key == pattern {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filter 按策略过滤 KEY=VALUE 形式的环境变量，platformVars 中的变量始终保留
+// 返回保留的变量和被丢弃的变量名（已排序）；只有 inherit 传递全部变量，其他取值一律按允许列表过滤
+func (p envPolicy) filter(environ []string, platformVars map[string]string) (kept []string, dropped []string) {
+	if p.Mode == envPolicyInherit {
+		return environ, nil
+	}
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, own := platformVars[key]; own || p.allowed(key) {
+			kept = append(kept, kv)
+		} else {
+			dropped = append(dropped, key)
+		}
+	}
+	sort.Strings(dropped)
+	return kept, dropped
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestResolveEnvPolicy tests merging of global and platform env policies
+func TestResolveEnvPolicy(t *testing.T) {
+	config := &Config{EnvPolicy: envPolicyAllowlist, EnvAllowlist: []string{"GOPATH"}}
+	platform := &Platform{Name: "prod", EnvAllowlist: []string{"CORP_*"}}
+
+	policy, err := resolveEnvPolicy(config, platform)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if policy.Mode != envPolicyAllowlist {
+		t.Errorf("Expected global allowlist policy, got %s", policy.Mode)
+	}
+	if !reflect.DeepEqual(policy.Allow, []string{"GOPATH", "CORP_*"}) {
+		t.Errorf("Expected merged allowlist, got %v", policy.Allow)
+	}
+
+	platform.EnvPolicy = envPolicyInherit
+	if got, _ := resolveEnvPolicy(config, platform); got.Mode != envPolicyInherit {
+		t.Errorf("Expected platform policy to win, got %s", got.Mode)
+	}
+	if got, _ := resolveEnvPolicy(&Config{}, &Platform{}); got.Mode != envPolicyInherit {
+		t.Errorf("Expected inherit by default, got %s", got.Mode)
+	}
+
+	// 拼写错误的 env_policy 在任一层级都应报错，而不是退回到 inherit
+	for _, c := range []struct {
+		config   *Config
+		platform *Platform
+	}{
+		{&Config{EnvPolicy: "allowlst"}, &Platform{Name: "prod"}},
+		{&Config{}, &Platform{Name: "prod", EnvPolicy: "allowlst"}},
+		{&Config{EnvPolicy: "allowlst"}, &Platform{Name: "prod", EnvPolicy: envPolicyInherit}},
+	} {
+		if _, err := resolveEnvPolicy(c.config, c.platform); err == nil || !strings.Contains(err.Error(), "allowlst") {
+			t.Errorf("Expected error for invalid env_policy, got %v", err)
+		}
+	}
+
+	if err := validateEnvPolicy("strict"); err == nil {
+		t.Error("Expected error for invalid env_policy, got nil")
+	}
+}
+
+// TestEnvPolicyFilter tests which variables survive the allowlist
+func TestEnvPolicyFilter(t *testing.T) {
+	environ := []string{
+		"PATH=/bin",
+		"LC_ALL=C",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"OPENAI_API_KEY=sk-x",
+		"CORP_PROXY=1",
+		"API_TIMEOUT_MS=10",
+		"ANTHROPIC_MODEL=m",
+	}
+	platformVars := map[string]string{"ANTHROPIC_MODEL": "m", "API_TIMEOUT_MS": "10"}
+
+	policy := envPolicy{Mode: envPolicyAllowlist, Allow: []string{"CORP_*"}}
+	kept, dropped := policy.filter(environ, platformVars)
+
+	wantKept := []string{"PATH=/bin", "LC_ALL=C", "CORP_PROXY=1", "API_TIMEOUT_MS=10", "ANTHROPIC_MODEL=m"}
+	if !reflect.DeepEqual(kept, wantKept) {
+		t.Errorf("Expected kept %v, got %v", wantKept, kept)
+	}
+	if want := []string{"AWS_SECRET_ACCESS_KEY", "OPENAI_API_KEY"}; !reflect.DeepEqual(dropped, want) {
+		t.Errorf("Expected dropped %v, got %v", want, dropped)
+	}
+
+	kept, dropped = envPolicy{Mode: envPolicyInherit}.filter(environ, platformVars)
+	if !reflect.DeepEqual(kept, environ) || dropped != nil {
+		t.Errorf("Expected inherit to keep everything, got %v (dropped %v)", kept, dropped)
+	}
+
+	// 未知的策略按允许列表过滤
+	for _, mode := range []string{"", "allowlst"} {
+		if kept, _ := (envPolicy{Mode: mode}).filter(environ, platformVars); len(kept) != 4 {
+			t.Errorf("Expected %q to fail closed, got %v", mode, kept)
+		}
+	}
+}
+
+// TestDryRunDroppedEnv tests that dry-run lists the dropped variables
+func TestDryRunDroppedEnv(t *testing.T) {
+	t.Setenv("CCGATE_TEST_SECRET", "x")
+	platform := &Platform{Name: "prod", AnthropicBaseURL: "https://a", AnthropicAuthToken: "sk-1234567890abcdef", AnthropicModel: "m",
+		EnvPolicy: envPolicyAllowlist}
+	res := &Resolution{Platform: platform, Source: SourceFlag}
+
+	out := newDryRunOutput(mustLaunchRequest(t, &Config{EnvAllowlist: []string{"GOFLAGS"}}, res, nil))
+	if out.EnvPolicy != envPolicyAllowlist {
+		t.Errorf("Expected allowlist policy, got %s", out.EnvPolicy)
+	}
+	// CCGATE_* 在默认允许列表中
+	for _, key := range out.DroppedEnv {
+		if key == "CCGATE_TEST_SECRET" || key == "PATH" {
+			t.Errorf("Expected %s to be kept", key)
+		}
+	}
+
+	t.Setenv("SOME_CLOUD_TOKEN", "x")
+	out = newDryRunOutput(mustLaunchRequest(t, &Config{}, res, nil))
+	found := false
+	for _, key := range out.DroppedEnv {
+		found = found || key == "SOME_CLOUD_TOKEN"
+	}
+	if !found {
+		t.Errorf("Expected SOME_CLOUD_TOKEN in dropped variables, got %v", out.DroppedEnv)
+	}
+}
+
+// TestInvalidEnvPolicyRefusesLaunch tests that launch, exec and fanout refuse to start with an unknown env_policy
+func TestInvalidEnvPolicyRefusesLaunch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := &Config{EnvPolicy: "allowlst", Platforms: []Platform{
+		{Name: "a", AnthropicBaseURL: "https://a.example.com", AnthropicAuthToken: "tok-a", AnthropicModel: "model-a"},
+		{Name: "b", AnthropicBaseURL: "https://b.example.com", AnthropicAuthToken: "tok-b", AnthropicModel: "model-b"},
+	}}
+	if err := saveConfig(config, path); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"-f", path, "-p", "a", "--dry-run"},
+		{"exec", "-f", path, "-p", "a", "--", "env"},
+		{"fanout", "-f", path, "-p", "a,b", "hi"},
+	} {
+		stdout, stderr, code := runCCGate(t, args...)
+		if code == 0 || strings.Contains(stdout, "tok-a") || !strings.Contains(stderr, "allowlst") {
+			t.Errorf("%q: expected refusal mentioning the invalid policy, got code %d, stdout %q, stderr %q", args, code, stdout, stderr)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"atomicgo.dev/cursor"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// exec 子命令
+var execCmd = &cobra.Command{
+	Use:   "exec -p <name> -- <command> [args...]",
+	Short: "使用平台环境变量执行任意命令",
+	Long: `使用指定平台的环境变量执行任意命令。
+
+环境变量的设置与启动 claude 时完全一致，命令的退出码原样返回，
+ccgate 不会向标准输出写入任何额外内容，适合在脚本中使用。
+
+示例:
+  ccgate exec -p prod -- python eval.py
+  ccgate exec -p kimi -- sh -c 'curl -s "$ANTHROPIC_BASE_URL/v1/models"'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		if err := checkConfig(config); err != nil {
+			return err
+		}
+
+		// 脚本场景下不做确认，也不打印执行信息
+		res, err := selectPlatform(config, platformName, nil, true)
+		if err != nil {
+			return err
+		}
+
+		policy, err := resolveEnvPolicy(config, res.Platform)
+		if err != nil {
+			return err
+		}
+		return execWithPlatform(res.Platform, policy, args)
+	},
+}
+
+func init() {
+	execCmd.Flags().StringVarP(&platformName, "platform", "p", "", "指定平台名称")
+	// 第一个位置参数之后的内容全部属于目标命令
+	execCmd.Flags().SetInterspersed(false)
+}
+
+// execWithPlatform 设置平台环境变量后以进程替换方式执行命令
+func execWithPlatform(platform *Platform, policy envPolicy, command []string) error {
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return fmt.Errorf("找不到可执行文件 '%s': %w", command[0], err)
+	}
+
+	// 进程替换，退出码由目标命令直接返回给调用方
+	env, err := platformEnviron(platform, policy)
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, command, env)
+}
+
+// reserveStdout 将 ccgate 自身的输出（错误信息、交互式选择器）改写到标准错误，
+// 标准输出只留给目标命令；进程替换后目标命令仍继承原来的标准输出
+func reserveStdout() {
+	os.Stdout = os.Stderr
+	pterm.SetDefaultOutput(os.Stderr)
+	cursor.SetTarget(os.Stderr)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain 设置 CCGATE_TEST_MAIN=1 时测试二进制直接作为 ccgate 运行，用于测试进程替换等端到端行为
+func TestMain(m *testing.M) {
+	if os.Getenv("CCGATE_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCCGate 以子进程运行 ccgate，返回标准输出、标准错误和退出码
+func runCCGate(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "CCGATE_TEST_MAIN=1", "HOME="+t.TempDir(), shellPlatformEnv+"=")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("Failed to run ccgate: %v", err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+// writeExecConfig 写入包含两个平台的配置文件
+func writeExecConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := &Config{Platforms: []Platform{
+		{Name: "a", AnthropicBaseURL: "https://a.example.com", AnthropicAuthToken: "tok-a", AnthropicModel: "model-a"},
+		{Name: "b", AnthropicBaseURL: "https://b.example.com", AnthropicAuthToken: "tok-b", AnthropicModel: "model-b"},
+	}}
+	if err := saveConfig(config, path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeFakeClaude writes an executable shell script that stands in for claude
+func writeFakeClaude(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestExecArgs tests that everything after the first positional argument belongs to the target command
+func TestExecArgs(t *testing.T) {
+	cfg := writeExecConfig(t)
+	script := `echo "$ANTHROPIC_MODEL $*"`
+
+	for _, args := range [][]string{
+		{"exec", "-f", cfg, "-p", "b", "sh", "-c", script, "sh", "-p", "x", "--help"},
+		{"exec", "-f", cfg, "-p", "b", "--", "sh", "-c", script, "sh", "-p", "x", "--help"},
+	} {
+		stdout, stderr, code := runCCGate(t, args...)
+		if code != 0 || stdout != "model-b -p x --help\n" || stderr != "" {
+			t.Errorf("%q: expected only the command's output, got code %d, stdout %q, stderr %q", args, code, stdout, stderr)
+		}
+	}
+}
+
+// TestExecExitCode tests that the target command's exit code is returned unchanged
+func TestExecExitCode(t *testing.T) {
+	stdout, _, code := runCCGate(t, "exec", "-f", writeExecConfig(t), "-p", "a", "--", "sh", "-c", "exit 7")
+	if code != 7 || stdout != "" {
+		t.Errorf("Expected exit code 7 and no output, got %d (stdout %q)", code, stdout)
+	}
+}
+
+// TestExecErrorsOnStderr tests that ccgate's own errors never reach stdout
+func TestExecErrorsOnStderr(t *testing.T) {
+	cfg := writeExecConfig(t)
+	for _, args := range [][]string{
+		{"exec", "-f", cfg, "-p", "zz", "--", "true"},
+		{"exec", "-f", cfg, "-p", "a", "--", "no-such-command-ccgate"},
+		{"exec", "-f", cfg, "-p", "a"},
+	} {
+		stdout, stderr, code := runCCGate(t, args...)
+		if code != 1 || stdout != "" || stderr == "" {
+			t.Errorf("%q: expected error on stderr only, got code %d, stdout %q, stderr %q", args, code, stdout, stderr)
+		}
+	}
+
+	// 未指定平台且无法交互选择时，提示同样写到标准错误
+	_, stderr, code := runCCGate(t, "exec", "-f", cfg, "--", "true")
+	if code != 1 || !strings.Contains(stderr, "a") {
+		t.Errorf("Expected non-interactive error listing platforms, got code %d, stderr %q", code, stderr)
+	}
+}
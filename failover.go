@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"atomicgo.dev/keyboard"
+	"atomicgo.dev/keyboard/keys"
+	"github.com/pterm/pterm"
+)
+
+// healthCheckTimeout 启动前健康检查的超时时间，需要足够短以免明显拖慢启动
+const healthCheckTimeout = 3 * time.Second
+
+// 健康检查失败后的处理方式，写入启动记录
+const (
+	failoverAuto        = "auto"        // --yes 自动切换到备用平台
+	failoverSwitched    = "switched"    // 在确认界面中选择切换
+	failoverKept        = "kept"        // 在确认界面中选择继续使用原平台
+	failoverUnavailable = "unavailable" // 没有可用的备用平台，继续使用原平台
+)
+
+// failoverRecord 启动前健康检查失败时的处理记录
+type failoverRecord struct {
+	// From 健康检查失败的平台，Reason 失败原因
+	From   string `json:"from"`
+	Reason string `json:"reason"`
+	// To 切换到的备用平台，继续使用原平台时为空
+	To     string `json:"to,omitempty"`
+	Choice string `json:"choice"`
+}
+
+// healthProbe 健康检查函数，测试中替换为不发送请求的实现
+type healthProbe func(platforms []Platform) []ProbeResult
+
+// healthCacheTTL 健康检查通过后的缓存时长，期间再次启动不重复检查
+const healthCacheTTL = 5 * time.Minute
+
+// healthCacheEntry 单个平台最近一次通过的健康检查
+type healthCacheEntry struct {
+	// BaseURL、Token 检查时的地址和令牌指纹，任一变化时缓存失效
+	BaseURL   string    `json:"base_url"`
+	Token     string    `json:"token"`
+	CheckedAt time.Time `json:"checked_at"`
+	LatencyMS int64     `json:"latency_ms"`
+}
+
+// probeHealth 并发检查平台的健康状态
+// 与 ccgate test 不同，这里请求不计费的模型列表接口；通过的结果缓存 healthCacheTTL
+func probeHealth(platforms []Platform) []ProbeResult {
+	check := func(p []Platform) []ProbeResult {
+		return checkPlatformsHealth(context.Background(), &http.Client{}, p, healthCheckTimeout)
+	}
+	return cachedHealth(platforms, healthCachePath(), time.Now(), check)
+}
+
+// cachedHealth 只检查没有有效缓存的平台，并更新缓存文件
+// 缓存读写失败不影响检查结果
+func cachedHealth(platforms []Platform, path string, now time.Time, check healthProbe) []ProbeResult {
+	cache := make(map[string]*healthCacheEntry)
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+
+	results := make([]ProbeResult, len(platforms))
+	var pending []Platform
+	var indexes []int
+	changed := false
+	for i := range platforms {
+		p := &platforms[i]
+		// 先获取 token_command 的令牌，使缓存按实际使用的令牌区分，令牌轮换后重新检查
+		if uiErr := ensurePlatformToken(p); uiErr != nil {
+			results[i] = ProbeResult{Platform: p.Name, URL: p.AnthropicBaseURL, Err: uiErr}
+			delete(cache, p.Name)
+			changed = true
+			continue
+		}
+		if e := cache[p.Name]; e != nil && e.BaseURL == p.AnthropicBaseURL && e.Token == tokenFingerprint(p.AnthropicAuthToken) &&
+			now.Sub(e.CheckedAt) >= 0 && now.Sub(e.CheckedAt) < healthCacheTTL {
+			results[i] = ProbeResult{Platform: p.Name, URL: p.AnthropicBaseURL, Latency: time.Duration(e.LatencyMS) * time.Millisecond}
+			continue
+		}
+		pending = append(pending, *p)
+		indexes = append(indexes, i)
+	}
+	if len(pending) == 0 && !changed {
+		return results
+	}
+
+	for j, r := range check(pending) {
+		results[indexes[j]] = r
+		p := &platforms[indexes[j]]
+		if r.Err != nil {
+			delete(cache, p.Name)
+			continue
+		}
+		cache[p.Name] = &healthCacheEntry{
+			BaseURL:   p.AnthropicBaseURL,
+			Token:     tokenFingerprint(p.AnthropicAuthToken),
+			CheckedAt: now,
+			LatencyMS: r.Latency.Milliseconds(),
+		}
+	}
+	if data, err := json.MarshalIndent(cache, "", "  "); err == nil {
+		_ = os.WriteFile(path, data, 0o600)
+	}
+	return results
+}
+
+// healthCachePath 返回健康检查缓存文件路径
+func healthCachePath() string {
+	return filepath.Join(filepath.Dir(getConfigPath()), "health-cache.json")
+}
+
+// checkPlatformsHealth 并发检查多个平台，结果顺序与输入一致
+func checkPlatformsHealth(ctx context.Context, client *http.Client, platforms []Platform, timeout time.Duration) []ProbeResult {
+	results := make([]ProbeResult, len(platforms))
+
+	var wg sync.WaitGroup
+	for i := range platforms {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i] = checkPlatformHealth(pctx, client, &platforms[i])
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// checkPlatformHealth 请求平台的模型列表接口（/v1/models，不存在时 /models）判断是否可用
+// 两个接口都不存在时平台仍然作出了响应，视为可用（此时不能确认令牌有效）
+func checkPlatformHealth(ctx context.Context, client *http.Client, platform *Platform) ProbeResult {
+	result := ProbeResult{Platform: platform.Name, URL: platform.AnthropicBaseURL}
+	if uiErr := ensurePlatformToken(platform); uiErr != nil {
+		result.Err = uiErr
+		return result
+	}
+
+	base := strings.TrimRight(platform.AnthropicBaseURL, "/")
+	start := time.Now()
+	for _, path := range []string{"/v1/models", "/models"} {
+		result.URL = base + path
+		var uiErr *UIError
+		_, result.StatusCode, uiErr = requestModels(ctx, client, result.URL, platform)
+		result.Latency = time.Since(start)
+		// 响应格式不受支持时请求本身已经成功
+		if uiErr == nil || (result.StatusCode >= 200 && result.StatusCode < 300) {
+			return result
+		}
+		if result.StatusCode != http.StatusNotFound && result.StatusCode != http.StatusMethodNotAllowed {
+			result.Err = uiErr
+			return result
+		}
+	}
+	return result
+}
+
+// failoverCandidate 第一个健康的备用平台
+type failoverCandidate struct {
+	Platform *Platform
+	Latency  time.Duration
+}
+
+// checkFailover 对配置了 fallback 的平台做启动前健康检查
+// 检查失败时显示原因：--yes 时自动切换到第一个健康的备用平台，否则在确认界面中提供切换
+// 返回最终使用的平台选择结果、处理记录（健康时为 nil）以及用户是否已在确认界面中确认
+func checkFailover(config *Config, res *Resolution, claudeArgs []string, skipConfirm bool, probe healthProbe) (*Resolution, *failoverRecord, bool, error) {
+	primary := res.Platform
+	if len(primary.Fallback) == 0 {
+		return res, nil, false, nil
+	}
+
+	theme := DefaultTheme()
+	result := probe([]Platform{*primary})[0]
+	if result.Err == nil {
+		return res, nil, false, nil
+	}
+	record := &failoverRecord{From: primary.Name, Reason: result.Err.Message}
+	DisplayWarning(fmt.Sprintf("平台 %s 健康检查失败: %s", primary.Name, record.Reason), theme)
+
+	candidate := firstHealthyFallback(config, primary, probe)
+	if candidate == nil {
+		record.Choice = failoverUnavailable
+		DisplayWarning(fmt.Sprintf("备用平台（%s）均不可用，继续使用 %s", strings.Join(primary.Fallback, "、"), primary.Name), theme)
+		return res, record, false, nil
+	}
+
+	if skipConfirm {
+		record.Choice = failoverAuto
+		record.To = candidate.Platform.Name
+		DisplayInfo(fmt.Sprintf("已自动切换到备用平台: %s（延迟 %s）", candidate.Platform.Name, candidate.Latency.Round(time.Millisecond)), theme)
+		return fallbackResolution(candidate.Platform, record), record, true, nil
+	}
+
+	switched, err := confirmFailover(primary, candidate, claudeArgs)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !switched {
+		record.Choice = failoverKept
+		return res, record, true, nil
+	}
+	record.Choice = failoverSwitched
+	record.To = candidate.Platform.Name
+	return fallbackResolution(candidate.Platform, record), record, true, nil
+}
+
+// firstHealthyFallback 并发检查全部备用平台，按配置顺序返回第一个健康的
+func firstHealthyFallback(config *Config, primary *Platform, probe healthProbe) *failoverCandidate {
+	var fallbacks []Platform
+	for _, name := range primary.Fallback {
+		p, err := findPlatformByName(config.Platforms, name)
+		if err != nil {
+			DisplayWarning(fmt.Sprintf("备用平台 %s 不存在，请检查 %s 的 fallback 设置", name, primary.Name), DefaultTheme())
+			continue
+		}
+		if p.Name == primary.Name {
+			continue
+		}
+		fallbacks = append(fallbacks, *p)
+	}
+	if len(fallbacks) == 0 {
+		return nil
+	}
+
+	for i, r := range probe(fallbacks) {
+		if r.Err == nil {
+			p, _ := findPlatformByName(config.Platforms, fallbacks[i].Name)
+			return &failoverCandidate{Platform: p, Latency: r.Latency}
+		}
+		DisplayWarning(fmt.Sprintf("备用平台 %s 不可用: %s", r.Platform, r.Err.Message), DefaultTheme())
+	}
+	return nil
+}
+
+// fallbackResolution 返回切换到备用平台后的选择结果
+func fallbackResolution(platform *Platform, record *failoverRecord) *Resolution {
+	detail := fmt.Sprintf("%s 健康检查失败", record.From)
+	return &Resolution{
+		Platform: platform,
+		Source:   SourceFallback,
+		Detail:   detail,
+		Steps: []ResolutionStep{{
+			Source:  SourceFallback,
+			Matched: true,
+			Detail:  fmt.Sprintf("%s → %s", detail, platform.Name),
+		}},
+	}
+}
+
+// confirmFailover 在确认界面中提供备用平台，返回是否切换
+// Enter/S 切换到备用平台，C 继续使用原平台，N/ESC 取消启动
+func confirmFailover(primary *Platform, candidate *failoverCandidate, claudeArgs []string) (bool, error) {
+	theme := DefaultTheme()
+
+	cmdText := "claude (交互式)"
+	if len(claudeArgs) > 0 {
+		cmdText = fmt.Sprintf("claude %s", strings.Join(claudeArgs, " "))
+	}
+	pterm.Info.Printf("执行命令: %s\n\n", theme.Colors.Info.Sprint(cmdText))
+
+	pterm.Printf("%s %s%s\n",
+		theme.Colors.Primary.Sprint("🚀 备用平台可用"),
+		theme.Colors.Secondary.Sprint(candidate.Platform.Name),
+		theme.Colors.Muted.Sprintf("（延迟 %s）", candidate.Latency.Round(time.Millisecond)))
+	Spacer(theme.Spacing.SM, theme)
+
+	pterm.Printf("%s %s %s %s\n",
+		theme.Colors.Primary.Sprint("切换平台?"),
+		theme.Colors.Success.Sprintf("[S] 切换到 %s", candidate.Platform.Name),
+		theme.Colors.Warning.Sprintf("[c] 继续使用 %s", primary.Name),
+		theme.Colors.Error.Sprint("[n] 取消"))
+	pterm.Printf("%s ", theme.Colors.Muted.Sprint("→ 按 Enter 或 S 切换，C 继续，N 或 ESC 取消"))
+
+	switched := false
+	cancelled := false
+	err := keyboard.Listen(func(key keys.Key) (stop bool, err error) {
+		switch key.Code {
+		case keys.Enter:
+			switched = true
+		case keys.RuneKey:
+			switch string(key.Runes) {
+			case "s", "S":
+				switched = true
+			case "c", "C":
+			case "n", "N":
+				cancelled = true
+			default:
+				return false, nil
+			}
+		case keys.Escape:
+			cancelled = true
+		case keys.CtrlC:
+			fmt.Println()
+			DisplayWarning("操作已取消", theme)
+			os.Exit(0)
+		default:
+			return false, nil
+		}
+		return true, nil
+	})
+	fmt.Println()
+	if err != nil {
+		return false, fmt.Errorf("键盘监听失败: %w", err)
+	}
+
+	switch {
+	case cancelled:
+		DisplayWarning("操作已取消", theme)
+		return false, fmt.Errorf("操作已取消")
+	case switched:
+		DisplaySuccess(fmt.Sprintf("切换到备用平台 %s", candidate.Platform.Name), theme)
+	default:
+		DisplaySuccess(fmt.Sprintf("继续使用 %s", primary.Name), theme)
+	}
+	return switched, nil
+}
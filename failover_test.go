@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProbe 返回健康检查函数，unhealthy 中的平台检查失败，并记录检查过的平台
+func fakeProbe(checked *[]string, unhealthy ...string) healthProbe {
+	down := make(map[string]bool)
+	for _, name := range unhealthy {
+		down[name] = true
+	}
+	return func(platforms []Platform) []ProbeResult {
+		results := make([]ProbeResult, len(platforms))
+		for i, p := range platforms {
+			*checked = append(*checked, p.Name)
+			results[i] = ProbeResult{Platform: p.Name, Latency: 50 * time.Millisecond}
+			if down[p.Name] {
+				results[i].Err = NewNetworkError("连接 "+p.Name+" 超时", "")
+			}
+		}
+		return results
+	}
+}
+
+func failoverConfig() *Config {
+	return &Config{Platforms: []Platform{
+		{Name: "prod", Fallback: []string{"backup", "spare"}},
+		{Name: "backup"},
+		{Name: "spare"},
+		{Name: "solo"},
+	}}
+}
+
+// TestCheckFailoverHealthy tests that healthy platforms and platforms without fallback launch unchanged
+func TestCheckFailoverHealthy(t *testing.T) {
+	config := failoverConfig()
+	for _, name := range []string{"prod", "solo"} {
+		var checked []string
+		res := &Resolution{Platform: &config.Platforms[0], Source: SourceDefault}
+		if name == "solo" {
+			res.Platform = &config.Platforms[3]
+		}
+		got, record, confirmed, err := checkFailover(config, res, nil, true, fakeProbe(&checked, "solo"))
+		if err != nil || got != res || record != nil || confirmed {
+			t.Errorf("%s: expected unchanged resolution, got %+v %+v %v %v", name, got, record, confirmed, err)
+		}
+		if name == "solo" && len(checked) != 0 {
+			t.Errorf("Expected no probe without fallback, got %v", checked)
+		}
+	}
+}
+
+// TestCheckFailoverAutoSwitch tests switching to the first healthy fallback with --yes
+func TestCheckFailoverAutoSwitch(t *testing.T) {
+	config := failoverConfig()
+	var checked []string
+	res := &Resolution{Platform: &config.Platforms[0], Source: SourceDefault, Detail: "ccgate use"}
+
+	got, record, confirmed, err := checkFailover(config, res, nil, true, fakeProbe(&checked, "prod", "backup"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Platform.Name != "spare" || got.Source != SourceFallback || !confirmed {
+		t.Errorf("Expected auto switch to spare, got %+v (confirmed=%v)", got, confirmed)
+	}
+	want := failoverRecord{From: "prod", Reason: "连接 prod 超时", To: "spare", Choice: failoverAuto}
+	if record == nil || *record != want {
+		t.Errorf("Expected %+v, got %+v", want, record)
+	}
+	if got.Summary() != "根据备用平台（prod 健康检查失败）使用平台: spare" {
+		t.Errorf("Unexpected summary %q", got.Summary())
+	}
+}
+
+// TestCheckFailoverUnavailable tests keeping the original platform when no fallback is healthy
+func TestCheckFailoverUnavailable(t *testing.T) {
+	config := failoverConfig()
+	var checked []string
+	res := &Resolution{Platform: &config.Platforms[0], Source: SourceFlag}
+
+	got, record, confirmed, err := checkFailover(config, res, nil, true, fakeProbe(&checked, "prod", "backup", "spare"))
+	if err != nil || got != res || confirmed {
+		t.Errorf("Expected original resolution, got %+v (confirmed=%v, err=%v)", got, confirmed, err)
+	}
+	if record == nil || record.Choice != failoverUnavailable || record.To != "" {
+		t.Errorf("Expected unavailable record, got %+v", record)
+	}
+	if len(checked) != 3 {
+		t.Errorf("Expected primary and both fallbacks to be probed, got %v", checked)
+	}
+}
+
+// TestValidateFallback tests fallback references in config validation
+func TestValidateFallback(t *testing.T) {
+	platform := func(name string, fallback ...string) Platform {
+		return Platform{Name: name, AnthropicBaseURL: "https://x", AnthropicAuthToken: "t", AnthropicModel: "m", Fallback: fallback}
+	}
+
+	valid := &Config{Platforms: []Platform{platform("a", "b"), platform("b")}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	for _, config := range []*Config{
+		{Platforms: []Platform{platform("a", "a")}},
+		{Platforms: []Platform{platform("a", "missing")}},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected error for %+v, got nil", config.Platforms[0].Fallback)
+		}
+	}
+}
+
+// TestCheckPlatformHealth tests that the health check only uses the unbilled model list endpoints
+func TestCheckPlatformHealth(t *testing.T) {
+	var requests []string
+	handler := func(status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			w.WriteHeader(status)
+			w.Write([]byte(`{"data":[{"id":"m"}]}`))
+		})
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		healthy bool
+		want    []string
+	}{
+		{"models", http.StatusOK, true, []string{"GET /v1/models"}},
+		{"no model list", http.StatusNotFound, true, []string{"GET /v1/models", "GET /models"}},
+		{"unauthorized", http.StatusUnauthorized, false, []string{"GET /v1/models"}},
+		{"server error", http.StatusBadGateway, false, []string{"GET /v1/models"}},
+	}
+	for _, tt := range tests {
+		requests = nil
+		server := httptest.NewServer(handler(tt.status))
+		platform := &Platform{Name: "p", AnthropicBaseURL: server.URL, AnthropicAuthToken: "t", AnthropicModel: "m"}
+		result := checkPlatformHealth(context.Background(), server.Client(), platform)
+		server.Close()
+
+		if (result.Err == nil) != tt.healthy {
+			t.Errorf("%s: expected healthy=%v, got %+v", tt.name, tt.healthy, result.Err)
+		}
+		if !reflect.DeepEqual(requests, tt.want) {
+			t.Errorf("%s: expected requests %v, got %v", tt.name, tt.want, requests)
+		}
+	}
+}
+
+// TestCachedHealth tests that healthy results are reused within the TTL and failures are not cached
+func TestCachedHealth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health-cache.json")
+	platforms := []Platform{
+		{Name: "up", AnthropicBaseURL: "https://up", AnthropicAuthToken: "t1"},
+		{Name: "down", AnthropicBaseURL: "https://down", AnthropicAuthToken: "t2"},
+	}
+	now := time.Now()
+
+	var checked []string
+	results := cachedHealth(platforms, path, now, fakeProbe(&checked, "down"))
+	if results[0].Err != nil || results[1].Err == nil || len(checked) != 2 {
+		t.Fatalf("Expected first check to probe both platforms, got %v (%+v)", checked, results)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected cache file with mode 0600, got %v", err)
+	}
+
+	checked = nil
+	results = cachedHealth(platforms, path, now.Add(time.Minute), fakeProbe(&checked, "down"))
+	if !reflect.DeepEqual(checked, []string{"down"}) || results[0].Err != nil || results[0].Latency != 50*time.Millisecond {
+		t.Errorf("Expected cached healthy result and only the unhealthy platform probed, got %v (%+v)", checked, results[0])
+	}
+
+	checked = nil
+	cachedHealth(platforms, path, now.Add(healthCacheTTL), fakeProbe(&checked))
+	if len(checked) != 2 {
+		t.Errorf("Expected expired cache to be checked again, got %v", checked)
+	}
+
+	checked = nil
+	changed := []Platform{{Name: "up", AnthropicBaseURL: "https://up", AnthropicAuthToken: "rotated"}}
+	cachedHealth(changed, path, now.Add(healthCacheTTL+time.Minute), fakeProbe(&checked))
+	if len(checked) != 1 {
+		t.Errorf("Expected token change to invalidate the cache, got %v", checked)
+	}
+}
+
+// TestCachedHealthTokenCommand tests that a token_command platform is cached by the token the command returns
+func TestCachedHealthTokenCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "health-cache.json")
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("tok-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	platforms := func() []Platform {
+		return []Platform{{Name: "gw", AnthropicBaseURL: "https://gw", TokenCommand: "cat " + tokenFile}}
+	}
+	now := time.Now()
+
+	var checked []string
+	cachedHealth(platforms(), path, now, fakeProbe(&checked))
+	checked = nil
+	cachedHealth(platforms(), path, now.Add(time.Minute), fakeProbe(&checked))
+	if len(checked) != 0 {
+		t.Errorf("Expected unchanged command token to use the cache, got %v", checked)
+	}
+
+	if err := os.WriteFile(tokenFile, []byte("tok-2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	checked = nil
+	cachedHealth(platforms(), path, now.Add(2*time.Minute), fakeProbe(&checked))
+	if len(checked) != 1 {
+		t.Errorf("Expected rotated command token to invalidate the cache, got %v", checked)
+	}
+
+	failing := []Platform{{Name: "gw", AnthropicBaseURL: "https://gw", TokenCommand: "exit 1"}}
+	checked = nil
+	results := cachedHealth(failing, path, now.Add(3*time.Minute), fakeProbe(&checked))
+	if len(checked) != 0 || results[0].Err == nil {
+		t.Errorf("Expected failing token_command to be reported without a probe, got %v (%+v)", checked, results[0])
+	}
+}
+
+// TestMissingFallbackRefusesLaunch tests that a mistyped fallback name is reported before any health check
+func TestMissingFallbackRefusesLaunch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := &Config{Platforms: []Platform{
+		{Name: "prod", AnthropicBaseURL: "https://a", AnthropicAuthToken: "t", AnthropicModel: "m", Fallback: []string{"bakup"}},
+		{Name: "backup", AnthropicBaseURL: "https://b", AnthropicAuthToken: "t", AnthropicModel: "m"},
+	}}
+	if err := saveConfig(config, path); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, code := runCCGate(t, "-f", path, "-p", "prod", "--yes", "--", "--version")
+	if code == 0 || !strings.Contains(stderr, "bakup") || strings.Contains(stderr, "健康检查") {
+		t.Errorf("Expected missing fallback to be reported before the health check, got code %d, stderr %q", code, stderr)
+	}
+}
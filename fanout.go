@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	fanoutPlatforms []string
+	fanoutJobs      int
+	fanoutTimeout   time.Duration
+	fanoutMarkdown  bool
+)
+
+// fanout 子命令
+var fanoutCmd = &cobra.Command{
+	Use:   "fanout -p <a,b,c> -- <claude 参数...>",
+	Short: "在多个平台上并发运行同一个 print 模式提示",
+	Long: `在多个平台上并发运行 claude 的 print 模式，对比各厂商的回答。
+
+每个平台在独立的环境中运行（与启动 claude 时的环境变量完全一致），
+收集各自的标准输出、耗时和退出码。参数中没有 -p/--print 时自动添加 --print。
+默认并排显示结果，--markdown 输出 Markdown，--output json|yaml 输出结构化数据。
+任一平台失败时退出码为 1。
+
+示例:
+  ccgate fanout -p kimi,glm,prod -- -p "用一句话解释 CAP 定理"
+  ccgate fanout -- "总结 README.md"              # 省略 -p 时使用所有平台
+  ccgate fanout -p kimi,glm --jobs 1 --markdown -- -p "review this diff" > compare.md`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if fanoutJobs < 1 {
+			return NewValidationError("--jobs 必须大于 0", "示例: --jobs 4")
+		}
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+		if err := checkConfig(config); err != nil {
+			return err
+		}
+
+		platforms, err := fanoutTargets(config, fanoutPlatforms)
+		if err != nil {
+			return err
+		}
+
+		claudePath, err := exec.LookPath("claude")
+		if err != nil {
+			return fmt.Errorf("找不到 claude 可执行文件: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Fprintf(os.Stderr, "→ 在 %d 个平台上运行（并发 %d）...\n", len(platforms), fanoutJobs)
+		results := runFanout(ctx, claudePath, config, platforms, printModeArgs(args), fanoutJobs, fanoutTimeout,
+			func(r fanoutResult) {
+				status := "✓"
+				if !r.ok() {
+					status = "✗"
+				}
+				fmt.Fprintf(os.Stderr, "  %s %s（%s）\n", status, r.Platform, formatDurationMS(r.DurationMS))
+			})
+
+		switch {
+		case structuredOutput():
+			err = writeStructured(results)
+		case fanoutMarkdown:
+			fmt.Print(renderFanoutMarkdown(results))
+		default:
+			fmt.Print(renderFanoutColumns(results, terminalWidth()))
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, r := range results {
+			if !r.ok() {
+				os.Exit(1)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	fanoutCmd.Flags().StringSliceVarP(&fanoutPlatforms, "platform", "p", nil, "逗号分隔的平台名称（省略时使用所有平台）")
+	fanoutCmd.Flags().IntVarP(&fanoutJobs, "jobs", "j", 4, "最多同时运行的平台数")
+	fanoutCmd.Flags().DurationVar(&fanoutTimeout, "timeout", 5*time.Minute, "单个平台的超时时间（0 表示不限制）")
+	fanoutCmd.Flags().BoolVar(&fanoutMarkdown, "markdown", false, "以 Markdown 输出结果")
+}
+
+// fanoutResult 单个平台的运行结果
+type fanoutResult struct {
+	Platform   string `json:"platform" yaml:"platform"`
+	Model      string `json:"model" yaml:"model"`
+	ExitCode   int    `json:"exit_code" yaml:"exit_code"`
+	DurationMS int64  `json:"duration_ms" yaml:"duration_ms"`
+	Stdout     string `json:"stdout" yaml:"stdout"`
+	Stderr     string `json:"stderr,omitempty" yaml:"stderr,omitempty"`
+	// Error claude 未能运行或被超时终止时的原因，未能运行时 ExitCode 为 -1
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// ok 平台是否成功返回
+func (r fanoutResult) ok() bool {
+	return r.ExitCode == 0 && r.Error == ""
+}
+
+// fanoutTargets 按名称查找平台，名称为空时返回所有平台
+func fanoutTargets(config *Config, names []string) ([]Platform, error) {
+	if len(config.Platforms) == 0 {
+		return nil, NewConfigError("没有配置任何平台", "运行 'ccgate add' 添加平台")
+	}
+	if len(names) == 0 {
+		return config.Platforms, nil
+	}
+
+	seen := make(map[string]bool, len(names))
+	platforms := make([]Platform, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			return nil, NewValidationError(fmt.Sprintf("平台 '%s' 重复", name), "每个平台只需指定一次")
+		}
+		seen[name] = true
+		platform, err := findPlatformByName(config.Platforms, name)
+		if err != nil {
+			return nil, unknownPlatformError(config.Platforms, name, err)
+		}
+		platforms = append(platforms, *platform)
+	}
+	return platforms, nil
+}
+
+// printModeArgs 确保 claude 以 print 模式运行
+func printModeArgs(args []string) []string {
+	for _, arg := range args {
+		if arg == "-p" || arg == "--print" {
+			return args
+		}
+	}
+	return append([]string{"--print"}, args...)
+}
+
+// runFanout 以最多 jobs 个并发在各平台上运行 claude，结果顺序与输入一致
+// 令牌在启动前依次获取，避免并发写入令牌缓存；每个子进程的环境由 composeEnviron 独立构建
+func runFanout(ctx context.Context, claudePath string, config *Config, platforms []Platform, claudeArgs []string,
+	jobs int, timeout time.Duration, done func(fanoutResult)) []fanoutResult {
+	results := make([]fanoutResult, len(platforms))
+	base := os.Environ()
+	envs := make([][]string, len(platforms))
+	for i := range platforms {
+		p := &platforms[i]
+		results[i] = fanoutResult{Platform: p.Name, Model: p.AnthropicModel}
+		policy, err := resolveEnvPolicy(config, p)
+		if err != nil {
+			results[i].ExitCode = -1
+			results[i].Error = err.Error()
+			continue
+		}
+		if uiErr := ensurePlatformToken(p); uiErr != nil {
+			results[i].ExitCode = -1
+			results[i].Error = uiErr.Message
+			continue
+		}
+		envs[i] = composeEnviron(base, p, policy)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan int)
+	for w := 0; w < jobs && w < len(platforms); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				if envs[i] != nil {
+					runFanoutOne(ctx, claudePath, claudeArgs, envs[i], timeout, &results[i])
+				}
+				if done != nil {
+					mu.Lock()
+					done(results[i])
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range platforms {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+// runFanoutOne 运行一次 claude 并记录输出、耗时和退出码
+func runFanoutOne(ctx context.Context, claudePath string, claudeArgs, env []string, timeout time.Duration, result *fanoutResult) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, claudePath, claudeArgs...)
+	cmd.Args[0] = "claude"
+	cmd.Env = env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// 超时或中断时先发送 SIGINT，让 claude 有机会正常退出
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result.DurationMS = time.Since(start).Milliseconds()
+	result.Stdout = stdout.String()
+	result.Stderr = strings.TrimSpace(stderr.String())
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Error = fmt.Sprintf("超过 %s 未完成，已终止", timeout)
+	case ctx.Err() != nil:
+		result.Error = "已中断"
+	case err != nil && !errors.As(err, &exitErr):
+		result.Error = err.Error()
+	}
+	if cmd.ProcessState != nil {
+		if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			result.ExitCode = 128 + int(ws.Signal())
+		} else {
+			result.ExitCode = cmd.ProcessState.ExitCode()
+		}
+	} else {
+		result.ExitCode = -1
+	}
+}
+
+// fanoutStatus 返回结果的简要状态，如 "exit 0 · 2.1s"
+func fanoutStatus(r fanoutResult) string {
+	status := fmt.Sprintf("exit %d · %s", r.ExitCode, formatDurationMS(r.DurationMS))
+	if r.Error != "" {
+		status += " · " + r.Error
+	}
+	return status
+}
+
+// fanoutOutput 返回用于显示的输出，失败且没有标准输出时显示标准错误
+func fanoutOutput(r fanoutResult) string {
+	out := strings.TrimRight(r.Stdout, "\n")
+	if out == "" && !r.ok() {
+		out = r.Stderr
+	}
+	return out
+}
+
+// minFanoutColumn 并排显示时每列的最小宽度，终端过窄时改为依次显示
+const minFanoutColumn = 24
+
+// renderFanoutColumns 将结果按列并排显示，列宽按终端宽度均分
+func renderFanoutColumns(results []fanoutResult, width int) string {
+	const gap = " │ "
+	n := len(results)
+	col := (width - (n-1)*runewidth.StringWidth(gap)) / n
+	if n == 1 || col < minFanoutColumn {
+		var b strings.Builder
+		for _, r := range results {
+			fmt.Fprintf(&b, "=== %s（%s）===\n%s\n\n", r.Platform, fanoutStatus(r), fanoutOutput(r))
+		}
+		return b.String()
+	}
+
+	columns := make([][]string, n)
+	rows := 0
+	for i, r := range results {
+		lines := []string{r.Platform, fanoutStatus(r), strings.Repeat("─", col)}
+		for _, line := range strings.Split(fanoutOutput(r), "\n") {
+			lines = append(lines, wrapLine(line, col)...)
+		}
+		columns[i] = lines
+		if len(lines) > rows {
+			rows = len(lines)
+		}
+	}
+
+	var b strings.Builder
+	for row := 0; row < rows; row++ {
+		cells := make([]string, n)
+		for i, lines := range columns {
+			cell := ""
+			if row < len(lines) {
+				cell = runewidth.Truncate(lines[row], col, "…")
+			}
+			cells[i] = runewidth.FillRight(cell, col)
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, gap), " "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// wrapLine 按显示宽度折行，中文等宽字符按两列计算
+func wrapLine(line string, width int) []string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	var lines []string
+	var cur strings.Builder
+	w := 0
+	for _, r := range line {
+		rw := runewidth.RuneWidth(r)
+		if w+rw > width {
+			lines = append(lines, cur.String())
+			cur.Reset()
+			w = 0
+		}
+		cur.WriteRune(r)
+		w += rw
+	}
+	return append(lines, cur.String())
+}
+
+// renderFanoutMarkdown 以 Markdown 输出：先是汇总表，然后是各平台的完整回答
+func renderFanoutMarkdown(results []fanoutResult) string {
+	var b strings.Builder
+	b.WriteString("| 平台 | 模型 | 退出码 | 耗时 |\n|---|---|---|---|\n")
+	for _, r := range results {
+		fmt.Fprintf(&b, "| %s | %s | %d | %s |\n", r.Platform, r.Model, r.ExitCode, formatDurationMS(r.DurationMS))
+	}
+	for _, r := range results {
+		fmt.Fprintf(&b, "\n## %s\n\n", r.Platform)
+		if r.Error != "" {
+			fmt.Fprintf(&b, "> %s\n\n", r.Error)
+		}
+		b.WriteString(fanoutOutput(r))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatDurationMS 将毫秒数格式化为便于阅读的时长
+func formatDurationMS(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(100 * time.Millisecond).String()
+}
+
+// terminalWidth 返回标准输出的终端宽度，不是终端时按 160 列处理
+func terminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	return 160
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestComposeEnviron tests that platform variables override the base environment without touching the process
+func TestComposeEnviron(t *testing.T) {
+	t.Setenv("ANTHROPIC_MODEL", "outer")
+	platform := &Platform{Name: "a", AnthropicBaseURL: "https://a.example.com", AnthropicAuthToken: "tok-a", AnthropicModel: "model-a"}
+	base := []string{"PATH=/bin", "ANTHROPIC_MODEL=outer", "SECRET_THING=1"}
+
+	env := composeEnviron(base, platform, envPolicy{Mode: envPolicyInherit})
+	want := []string{"PATH=/bin", "SECRET_THING=1", "ANTHROPIC_AUTH_TOKEN=tok-a", "ANTHROPIC_BASE_URL=https://a.example.com", "ANTHROPIC_MODEL=model-a"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("Expected %q, got %q", want, env)
+	}
+	if os.Getenv("ANTHROPIC_MODEL") != "outer" {
+		t.Error("Expected process environment to be unchanged")
+	}
+
+	env = composeEnviron(base, platform, envPolicy{Mode: envPolicyAllowlist, Allow: []string{"PATH"}})
+	if strings.Contains(strings.Join(env, "\n"), "SECRET_THING") {
+		t.Errorf("Expected allowlist to drop SECRET_THING, got %q", env)
+	}
+}
+
+// TestComposeEnvironTokenKey tests that an inherited token for the other auth mode is removed
+func TestComposeEnvironTokenKey(t *testing.T) {
+	base := []string{"ANTHROPIC_AUTH_TOKEN=outer-token", "ANTHROPIC_API_KEY=outer-key", "HOME=/home/u"}
+	policy := envPolicy{Mode: envPolicyInherit}
+
+	apiKey := &Platform{Name: "k", AnthropicBaseURL: "https://k", AnthropicAuthToken: "tok-k", AnthropicModel: "m", AuthMode: authModeAPIKey}
+	want := []string{"HOME=/home/u", "ANTHROPIC_API_KEY=tok-k", "ANTHROPIC_BASE_URL=https://k", "ANTHROPIC_MODEL=m"}
+	if env := composeEnviron(base, apiKey, policy); !reflect.DeepEqual(env, want) {
+		t.Errorf("api_key: expected %q, got %q", want, env)
+	}
+
+	token := &Platform{Name: "t", AnthropicBaseURL: "https://t", AnthropicAuthToken: "tok-t", AnthropicModel: "m"}
+	want = []string{"HOME=/home/u", "ANTHROPIC_AUTH_TOKEN=tok-t", "ANTHROPIC_BASE_URL=https://t", "ANTHROPIC_MODEL=m"}
+	if env := composeEnviron(base, token, policy); !reflect.DeepEqual(env, want) {
+		t.Errorf("auth_token: expected %q, got %q", want, env)
+	}
+
+	// 平台 env 中显式设置的变量仍然保留
+	token.Env = map[string]string{"ANTHROPIC_API_KEY": "explicit"}
+	if env := composeEnviron(base, token, policy); !strings.Contains(strings.Join(env, "\n"), "ANTHROPIC_API_KEY=explicit") {
+		t.Errorf("Expected explicit platform env to be kept, got %q", env)
+	}
+}
+
+// TestRunFanout tests running a fake claude against several platforms with a bounded pool
+func TestRunFanout(t *testing.T) {
+	path := writeFakeClaude(t, `echo "$ANTHROPIC_MODEL $*"
+[ "$ANTHROPIC_MODEL" = "model-b" ] && { echo "boom" >&2; exit 2; }
+[ "$ANTHROPIC_MODEL" = "model-c" ] && exec sleep 5
+exit 0`)
+	config := &Config{Platforms: []Platform{
+		{Name: "a", AnthropicBaseURL: "https://a.example.com", AnthropicAuthToken: "tok-a", AnthropicModel: "model-a"},
+		{Name: "b", AnthropicBaseURL: "https://b.example.com", AnthropicAuthToken: "tok-b", AnthropicModel: "model-b"},
+		{Name: "c", AnthropicBaseURL: "https://c.example.com", AnthropicAuthToken: "tok-c", AnthropicModel: "model-c"},
+	}}
+
+	var finished []string
+	results := runFanout(context.Background(), path, config, config.Platforms, printModeArgs([]string{"hi"}), 2,
+		500*time.Millisecond, func(r fanoutResult) { finished = append(finished, r.Platform) })
+
+	if len(results) != 3 || len(finished) != 3 {
+		t.Fatalf("Expected 3 results, got %d (finished %v)", len(results), finished)
+	}
+	if r := results[0]; r.Platform != "a" || r.Stdout != "model-a --print hi\n" || !r.ok() {
+		t.Errorf("Unexpected result for a: %+v", r)
+	}
+	if r := results[1]; r.ExitCode != 2 || r.Stderr != "boom" || r.ok() {
+		t.Errorf("Unexpected result for b: %+v", r)
+	}
+	if r := results[2]; r.ok() || !strings.Contains(r.Error, "500ms") {
+		t.Errorf("Expected timeout for c, got %+v", r)
+	}
+}
+
+// TestFanoutTargets tests platform list validation
+func TestFanoutTargets(t *testing.T) {
+	config := &Config{Platforms: []Platform{{Name: "a"}, {Name: "b"}}}
+
+	if got, err := fanoutTargets(config, nil); err != nil || len(got) != 2 {
+		t.Errorf("Expected all platforms, got %v (%v)", got, err)
+	}
+	if got, err := fanoutTargets(config, []string{"b", " a"}); err != nil || got[0].Name != "b" || got[1].Name != "a" {
+		t.Errorf("Expected b,a in order, got %v (%v)", got, err)
+	}
+	if _, err := fanoutTargets(config, []string{"a", "a"}); err == nil {
+		t.Error("Expected error for duplicate platform, got nil")
+	}
+	if _, err := fanoutTargets(config, []string{"x"}); err == nil {
+		t.Error("Expected error for unknown platform, got nil")
+	}
+}
+
+// TestRenderFanout tests side-by-side and Markdown rendering
+func TestRenderFanout(t *testing.T) {
+	results := []fanoutResult{
+		{Platform: "kimi", Model: "k2", DurationMS: 1200, Stdout: "你好世界你好世界你好世界你好世界你好世界\n"},
+		{Platform: "glm", Model: "glm-4", ExitCode: 1, DurationMS: 300, Stderr: "401"},
+	}
+
+	columns := renderFanoutColumns(results, 63)
+	lines := strings.Split(strings.TrimRight(columns, "\n"), "\n")
+	if !strings.HasPrefix(lines[0], "kimi") || !strings.Contains(lines[0], "│ glm") {
+		t.Errorf("Expected platform headers side by side, got %q", lines[0])
+	}
+	if !strings.Contains(columns, "│ 401") {
+		t.Errorf("Expected stderr for failed platform, got:\n%s", columns)
+	}
+	if len(lines) != 5 {
+		t.Errorf("Expected wide characters to wrap onto two lines, got:\n%s", columns)
+	}
+
+	if narrow := renderFanoutColumns(results, 40); !strings.Contains(narrow, "=== kimi（exit 0 · 1.2s）===") {
+		t.Errorf("Expected sequential layout on narrow terminals, got:\n%s", narrow)
+	}
+
+	md := renderFanoutMarkdown(results)
+	if !strings.Contains(md, "| glm | glm-4 | 1 | 300ms |") || !strings.Contains(md, "## kimi\n\n你好") {
+		t.Errorf("Unexpected markdown:\n%s", md)
+	}
+}
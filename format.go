@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// listTablePrefix --format 以该前缀开头时按列对齐输出并添加表头
+const listTablePrefix = "table "
+
+// platformTemplateData list --format 模板的数据：Platform 的全部字段加上计算字段
+type platformTemplateData struct {
+	Platform
+	// Index 从 1 开始的序号
+	Index int
+	// MaskedToken 掩码后的令牌
+	MaskedToken string
+	// TokenFingerprint 令牌指纹（sha256 前缀），与 ccgate diff 一致
+	TokenFingerprint string
+	// TokenEnv 令牌对应的环境变量名
+	TokenEnv string
+	// Default 是否为默认平台
+	Default bool
+	// LastUsed 最后一次通过 ccgate 启动的时间，从未使用时为零值
+	LastUsed time.Time
+}
+
+// templateFuncs list --format 可用的辅助函数
+var templateFuncs = template.FuncMap{
+	// pad 右侧补空格到指定显示宽度（中文等宽字符计为 2）
+	"pad": func(width int, s string) string { return runewidth.FillRight(s, width) },
+	// lpad 左侧补空格到指定显示宽度
+	"lpad": func(width int, s string) string { return runewidth.FillLeft(s, width) },
+	// truncate 超出显示宽度时截断并以 … 结尾
+	"truncate": func(width int, s string) string {
+		if width < 1 {
+			return s
+		}
+		return runewidth.Truncate(s, width, "…")
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  func(sep string, items []string) string { return strings.Join(items, sep) },
+	// default 值为空时使用默认值
+	"default": func(def, s string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	},
+	// ago 将时间格式化为相对时间，零值输出 never
+	"ago": func(t time.Time) string { return humanizeSince(t, time.Now()) },
+	// json 输出值的 JSON 表示
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// renderPlatformTemplate 使用 Go 模板逐行输出平台，table 前缀时按列对齐并输出表头
+func renderPlatformTemplate(w io.Writer, format string, platforms []Platform, config *Config, lastUsed map[string]time.Time) error {
+	// 与 docker --format 一致，允许在 shell 单引号中使用 \t 和 \n
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+
+	table := strings.HasPrefix(format, listTablePrefix)
+	if table {
+		format = strings.TrimPrefix(format, listTablePrefix)
+	}
+
+	tmpl, err := template.New("list").Funcs(templateFuncs).Option("missingkey=zero").Parse(format)
+	if err != nil {
+		return NewValidationError(
+			fmt.Sprintf("--format 模板无效: %v", err),
+			"模板语法参考 https://pkg.go.dev/text/template，例如 '{{.Name}}\\t{{.AnthropicModel}}'")
+	}
+
+	// table 模式先收集所有行，按显示宽度对齐后再输出
+	out := w
+	var rows bytes.Buffer
+	if table {
+		out = &rows
+		fmt.Fprintln(out, templateHeader(format))
+	}
+
+	var buf bytes.Buffer
+	for i := range platforms {
+		p := &platforms[i]
+		data := platformTemplateData{
+			Platform:         *p,
+			Index:            i + 1,
+			MaskedToken:      maskToken(p.AnthropicAuthToken),
+			TokenFingerprint: tokenFingerprint(p.AnthropicAuthToken),
+			TokenEnv:         p.tokenEnvKey(),
+			Default:          config.Default == p.Name,
+			LastUsed:         lastUsed[p.Name],
+		}
+
+		buf.Reset()
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return NewValidationError(
+				fmt.Sprintf("执行 --format 模板失败: %v", err),
+				"检查模板中的字段名，可用字段见 'ccgate list --help'")
+		}
+		fmt.Fprintln(out, buf.String())
+	}
+
+	if table {
+		_, err := io.WriteString(w, alignColumns(rows.String()))
+		return err
+	}
+	return nil
+}
+
+// tableColumnGap 对齐后列与列之间的空格数
+const tableColumnGap = 2
+
+// alignColumns 按制表符分列并以显示宽度对齐（text/tabwriter 按字符计数，中文会错位）
+// 与 tabwriter 相同，每行最后一个制表符之后的内容不参与对齐
+func alignColumns(text string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	cells := make([][]string, len(lines))
+	var widths []int
+	for i, line := range lines {
+		cells[i] = strings.Split(line, "\t")
+		for j, cell := range cells[i][:len(cells[i])-1] {
+			if j == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], runewidth.StringWidth(cell))
+		}
+	}
+
+	var b strings.Builder
+	for _, row := range cells {
+		for j, cell := range row {
+			if j < len(row)-1 {
+				cell = runewidth.FillRight(cell, widths[j]+tableColumnGap)
+			}
+			b.WriteString(cell)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// templateFieldPattern 匹配模板中引用的第一个字段，用于生成表头
+var templateFieldPattern = regexp.MustCompile(`\{\{[^}]*?\.(\w+)`)
+
+// templateHeader 根据每列引用的字段生成表头，如 {{.Name}}\t{{.AnthropicModel}} → NAME	ANTHROPIC_MODEL
+func templateHeader(format string) string {
+	columns := strings.Split(format, "\t")
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		if m := templateFieldPattern.FindStringSubmatch(col); m != nil {
+			headers[i] = headerName(m[1])
+		}
+	}
+	return strings.Join(headers, "\t")
+}
+
+// headerName 将驼峰字段名转换为大写下划线形式
+func headerName(field string) string {
+	var b strings.Builder
+	runes := []rune(field)
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+		if i > 0 && upper && (runes[i-1] < 'A' || runes[i-1] > 'Z' ||
+			(i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z')) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
+
+// humanizeSince 返回 t 距离 now 的简短相对时间，如 5m ago、3h ago、2d ago
+func humanizeSince(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// TestRenderPlatformTemplate tests template output with computed fields and helpers
+func TestRenderPlatformTemplate(t *testing.T) {
+	platforms := []Platform{
+		{Name: "prod", AnthropicModel: "claude-sonnet-4", AnthropicAuthToken: "sk-1234567890abcdef"},
+		{Name: "kimi", AnthropicModel: "kimi-k2"},
+	}
+	config := &Config{Default: "kimi"}
+	lastUsed := map[string]time.Time{"prod": time.Now().Add(-2 * time.Hour)}
+
+	var buf bytes.Buffer
+	format := `{{pad 6 .Name}}|{{.MaskedToken}}|{{ago .LastUsed}}{{if .Default}} *{{end}}`
+	if err := renderPlatformTemplate(&buf, format, platforms, config, lastUsed); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := "prod  |sk-1****cdef|2h ago\nkimi  |****|never *\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := renderPlatformTemplate(&buf, `table {{.Name}}\t{{.AnthropicModel}}`, platforms, config, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[0], "ANTHROPIC_MODEL") {
+		t.Errorf("Expected aligned table with header, got %q", buf.String())
+	}
+
+	// 中文平台名按显示宽度对齐，第二列的起始列一致
+	buf.Reset()
+	cjk := []Platform{{Name: "月之暗面", AnthropicModel: "kimi-k2"}, {Name: "glm", AnthropicModel: "glm-4.6"}}
+	if err := renderPlatformTemplate(&buf, `table {{.Name}}\t{{.AnthropicModel}}`, cjk, config, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want = "NAME      ANTHROPIC_MODEL\n月之暗面  kimi-k2\nglm       glm-4.6\n"
+	if buf.String() != want {
+		t.Errorf("Expected display width aligned table %q, got %q", want, buf.String())
+	}
+
+	if err := renderPlatformTemplate(&buf, `{{.Nope}}`, platforms, config, nil); err == nil {
+		t.Error("Expected error for unknown field, got nil")
+	}
+	if err := renderPlatformTemplate(&buf, `{{.Name`, platforms, config, nil); err == nil {
+		t.Error("Expected error for invalid template, got nil")
+	}
+}
+
+// TestTemplateHelpers tests padding, truncation and header naming helpers
+func TestTemplateHelpers(t *testing.T) {
+	pad := templateFuncs["pad"].(func(int, string) string)
+	lpad := templateFuncs["lpad"].(func(int, string) string)
+	truncate := templateFuncs["truncate"].(func(int, string) string)
+
+	if got := pad(6, "中文"); got != "中文  " {
+		t.Errorf("Expected display width based padding, got %q", got)
+	}
+	if got := pad(4, "中文"); got != "中文" {
+		t.Errorf("Expected no padding for a string already 4 columns wide, got %q", got)
+	}
+	if got := lpad(4, "ab"); got != "  ab" {
+		t.Errorf("Expected left padding, got %q", got)
+	}
+	if got := lpad(5, "月之"); got != " 月之" {
+		t.Errorf("Expected display width based left padding, got %q", got)
+	}
+	if got := truncate(5, "claude-sonnet"); got != "clau…" {
+		t.Errorf("Expected truncated string, got %q", got)
+	}
+	if got := truncate(5, "通义千问模型"); runewidth.StringWidth(got) > 5 || !strings.HasPrefix(got, "通义") {
+		t.Errorf("Expected truncation to 5 columns, got %q", got)
+	}
+	if got := headerName("AnthropicBaseURL"); got != "ANTHROPIC_BASE_URL" {
+		t.Errorf("Expected ANTHROPIC_BASE_URL, got %s", got)
+	}
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// HistoryEntry 一次 claude 启动记录（history.jsonl 中的一行）
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`
+	Platform string    `json:"platform"`
+	// Source 平台的选择依据（flag、env、directory 等）
+	Source  string   `json:"source,omitempty"`
+	Cwd     string   `json:"cwd"`
+	GitRepo string   `json:"git_repo,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	// 以下字段仅在托管模式下记录，exec 模式下 ccgate 已被 claude 替换
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Signal     string `json:"signal,omitempty"`
+	// EndReason 会话被 ccgate 中断的原因（max_session、idle_timeout）
+	EndReason string `json:"end_reason,omitempty"`
+	// Recording 会话录制文件（--record）
+	Recording string `json:"recording,omitempty"`
+	// Failover 启动前健康检查失败时的处理（切换到备用平台或继续使用原平台）
+	Failover *failoverRecord `json:"failover,omitempty"`
+}
+
+// historyFilter history 与 stats 的过滤条件
+type historyFilter struct {
+	Platform string
+	Dir      string
+	Since    time.Time
+	Until    time.Time
+}
+
+var (
+	historyPlatform string
+	historyDir      string
+	historySince    string
+	historyUntil    string
+	historyLimit    int
+)
+
+// history 子命令
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "查看 claude 的启动记录",
+	Long: `列出通过 ccgate 启动 claude 的记录（最近的在最后）。
+
+记录保存在 ~/.ccgate/history.jsonl，包含时间、平台、工作目录、
+所在 git 仓库和参数（令牌已脱敏）。
+
+示例:
+  ccgate history
+  ccgate history -p prod --since 2025-01-01
+  ccgate history --dir . -n 50`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := buildHistoryFilter()
+		if err != nil {
+			return err
+		}
+		entries, err := loadHistory()
+		if err != nil {
+			return err
+		}
+		entries = filterHistory(entries, filter)
+		if historyLimit > 0 && len(entries) > historyLimit {
+			entries = entries[len(entries)-historyLimit:]
+		}
+		printHistory(entries)
+		return nil
+	},
+}
+
+// stats 子命令
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "按平台、日期和仓库统计 claude 的使用情况",
+	Long: `根据启动记录统计各平台的使用次数、占比和最后使用时间，
+最近 14 天（有启动记录的日期）每天各平台的启动次数，
+以及使用最多的仓库（不在 git 仓库中时按目录统计）。
+
+示例:
+  ccgate stats
+  ccgate stats --since 2025-01-01`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := buildHistoryFilter()
+		if err != nil {
+			return err
+		}
+		entries, err := loadHistory()
+		if err != nil {
+			return err
+		}
+		printStats(filterHistory(entries, filter))
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{historyCmd, statsCmd} {
+		cmd.Flags().StringVarP(&historyPlatform, "platform", "p", "", "只显示指定平台的记录")
+		cmd.Flags().StringVar(&historyDir, "dir", "", "只显示该目录（含子目录）中的记录")
+		cmd.Flags().StringVar(&historySince, "since", "", "起始日期（YYYY-MM-DD）")
+		cmd.Flags().StringVar(&historyUntil, "until", "", "截止日期（YYYY-MM-DD，包含当天）")
+	}
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "最多显示的记录数（0 表示全部）")
+}
+
+// historyPath 返回启动记录文件路径
+func historyPath() string {
+	return filepath.Join(filepath.Dir(getConfigPath()), "history.jsonl")
+}
+
+// newHistoryEntry 创建当前目录下的启动记录
+func newHistoryEntry(platform *Platform, source ResolutionSource, claudeArgs []string) HistoryEntry {
+	cwd, _ := os.Getwd()
+	return HistoryEntry{
+		Time:     time.Now(),
+		Platform: platform.Name,
+		Source:   string(source),
+		Cwd:      cwd,
+		GitRepo:  gitToplevel(cwd),
+		Args:     redactArgs(claudeArgs, []string{platform.AnthropicAuthToken}),
+	}
+}
+
+// gitToplevel 返回目录所在 git 仓库的根目录，不在仓库中时返回空字符串
+func gitToplevel(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// secretPattern 匹配常见的 API Key 格式
+var secretPattern = regexp.MustCompile(`sk-[A-Za-z0-9_\-]{16,}`)
+
+// redactArgs 掩码参数中出现的令牌和疑似 API Key
+func redactArgs(args []string, secrets []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	out := make([]string, len(args))
+	for i, arg := range args {
+		for _, secret := range secrets {
+			if len(secret) >= 8 {
+				arg = strings.ReplaceAll(arg, secret, maskToken(secret))
+			}
+		}
+		out[i] = secretPattern.ReplaceAllStringFunc(arg, maskToken)
+	}
+	return out
+}
+
+// appendHistory 追加一条启动记录
+func appendHistory(entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	path := historyPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// recordLaunch 记录一次启动，失败时只给出警告，不影响启动
+func recordLaunch(entry HistoryEntry) {
+	if err := appendHistory(entry); err != nil {
+		DisplayWarning(fmt.Sprintf("写入启动记录失败: %v", err), DefaultTheme())
+	}
+}
+
+// loadHistory 读取全部启动记录，跳过无法解析的行
+func loadHistory() ([]HistoryEntry, error) {
+	f, err := os.Open(historyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取启动记录失败: %w", err)
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取启动记录失败: %w", err)
+	}
+	return entries, nil
+}
+
+// buildHistoryFilter 根据命令行参数构建过滤条件
+func buildHistoryFilter() (historyFilter, error) {
+	filter := historyFilter{Platform: historyPlatform}
+
+	if historyDir != "" {
+		dir, err := filepath.Abs(historyDir)
+		if err != nil {
+			return filter, fmt.Errorf("解析目录失败: %w", err)
+		}
+		filter.Dir = dir
+	}
+	if historySince != "" {
+		t, err := time.ParseInLocation("2006-01-02", historySince, time.Local)
+		if err != nil {
+			return filter, NewValidationError(
+				fmt.Sprintf("--since 日期格式无效: %s", historySince),
+				"使用 YYYY-MM-DD 格式，例如 2025-01-01")
+		}
+		filter.Since = t
+	}
+	if historyUntil != "" {
+		t, err := time.ParseInLocation("2006-01-02", historyUntil, time.Local)
+		if err != nil {
+			return filter, NewValidationError(
+				fmt.Sprintf("--until 日期格式无效: %s", historyUntil),
+				"使用 YYYY-MM-DD 格式，例如 2025-01-31")
+		}
+		filter.Until = t.AddDate(0, 0, 1)
+	}
+	return filter, nil
+}
+
+// filterHistory 返回满足过滤条件的记录
+func filterHistory(entries []HistoryEntry, filter historyFilter) []HistoryEntry {
+	var out []HistoryEntry
+	for _, e := range entries {
+		if filter.Platform != "" && e.Platform != filter.Platform {
+			continue
+		}
+		if filter.Dir != "" && e.Cwd != filter.Dir && !strings.HasPrefix(e.Cwd, filter.Dir+string(filepath.Separator)) {
+			continue
+		}
+		if !filter.Since.IsZero() && e.Time.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !e.Time.Before(filter.Until) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// lastUsedByPlatform 返回每个平台最后一次启动的时间
+func lastUsedByPlatform(entries []HistoryEntry) map[string]time.Time {
+	last := make(map[string]time.Time)
+	for _, e := range entries {
+		if e.Time.After(last[e.Platform]) {
+			last[e.Platform] = e.Time
+		}
+	}
+	return last
+}
+
+// shortenHome 将路径中的用户主目录替换为 ~
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
+// printHistory 以表格形式输出启动记录
+func printHistory(entries []HistoryEntry) {
+	theme := DefaultTheme()
+
+	if len(entries) == 0 {
+		DisplayInfo("没有匹配的启动记录", theme)
+		return
+	}
+
+	tableData := pterm.TableData{{"时间", "平台", "目录", "参数", "退出码", "时长"}}
+	for _, e := range entries {
+		exitCode := "-"
+		if e.ExitCode != nil {
+			exitCode = fmt.Sprintf("%d", *e.ExitCode)
+		}
+		if e.Signal != "" {
+			exitCode += " (" + e.Signal + ")"
+		}
+		if e.EndReason != "" {
+			exitCode += " [" + e.EndReason + "]"
+		}
+		duration := "-"
+		if e.DurationMS > 0 {
+			duration = (time.Duration(e.DurationMS) * time.Millisecond).Round(time.Second).String()
+		}
+		platform := theme.Colors.Primary.Sprint(e.Platform)
+		if e.Failover != nil && e.Failover.To != "" {
+			platform += theme.Colors.Muted.Sprintf(" ← %s", e.Failover.From)
+		}
+		tableData = append(tableData, []string{
+			e.Time.Local().Format("2006-01-02 15:04"),
+			platform,
+			shortenHome(e.Cwd),
+			strings.Join(e.Args, " "),
+			exitCode,
+			duration,
+		})
+	}
+
+	pterm.DefaultTable.WithHasHeader(true).
+		WithBoxed(true).
+		WithData(tableData).
+		Render()
+	Spacer(theme.Spacing.XS, theme)
+}
+
+// usageCount 统计项及其次数
+type usageCount struct {
+	Key   string
+	Count int
+}
+
+// countBy 按 key 统计次数，按次数降序、key 升序排列
+func countBy(entries []HistoryEntry, key func(HistoryEntry) string) []usageCount {
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[key(e)]++
+	}
+	out := make([]usageCount, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, usageCount{Key: k, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// statsMaxDays stats 按天统计时最多显示的天数（有启动记录的最近几天）
+const statsMaxDays = 14
+
+// dailyUsage 某一天各平台的启动次数
+type dailyUsage struct {
+	Day    string // 本地日期，YYYY-MM-DD
+	Counts map[string]int
+	Total  int
+}
+
+// countByDay 按本地日期统计各平台的启动次数，按日期从新到旧排列
+func countByDay(entries []HistoryEntry) []dailyUsage {
+	byDay := make(map[string]*dailyUsage)
+	for _, e := range entries {
+		day := e.Time.Local().Format("2006-01-02")
+		d, ok := byDay[day]
+		if !ok {
+			d = &dailyUsage{Day: day, Counts: make(map[string]int)}
+			byDay[day] = d
+		}
+		d.Counts[e.Platform]++
+		d.Total++
+	}
+	out := make([]dailyUsage, 0, len(byDay))
+	for _, d := range byDay {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day > out[j].Day })
+	return out
+}
+
+// printStats 输出按平台、日期和仓库的使用统计
+func printStats(entries []HistoryEntry) {
+	theme := DefaultTheme()
+
+	if len(entries) == 0 {
+		DisplayInfo("没有匹配的启动记录", theme)
+		return
+	}
+
+	title := fmt.Sprintf("共启动 %d 次（%s 至 %s）", len(entries),
+		entries[0].Time.Local().Format("2006-01-02"),
+		entries[len(entries)-1].Time.Local().Format("2006-01-02"))
+	pterm.Info.Printf("%s\n", theme.Colors.Primary.Sprint(title))
+	Spacer(theme.Spacing.XS, theme)
+
+	lastUsed := lastUsedByPlatform(entries)
+	platformData := pterm.TableData{{"平台", "次数", "占比", "最后使用"}}
+	for _, c := range countBy(entries, func(e HistoryEntry) string { return e.Platform }) {
+		platformData = append(platformData, []string{
+			theme.Colors.Primary.Sprint(c.Key),
+			fmt.Sprintf("%d", c.Count),
+			fmt.Sprintf("%.1f%%", float64(c.Count)*100/float64(len(entries))),
+			lastUsed[c.Key].Local().Format("2006-01-02 15:04"),
+		})
+	}
+	pterm.DefaultTable.WithHasHeader(true).WithBoxed(true).WithData(platformData).Render()
+	Spacer(theme.Spacing.XS, theme)
+
+	// 按天统计，列为各平台（按总次数排序）
+	platforms := countBy(entries, func(e HistoryEntry) string { return e.Platform })
+	header := []string{"日期"}
+	for _, c := range platforms {
+		header = append(header, c.Key)
+	}
+	dayData := pterm.TableData{append(header, "合计")}
+	days := countByDay(entries)
+	if len(days) > statsMaxDays {
+		days = days[:statsMaxDays]
+	}
+	for _, d := range days {
+		row := []string{d.Day}
+		for _, c := range platforms {
+			if n := d.Counts[c.Key]; n > 0 {
+				row = append(row, fmt.Sprintf("%d", n))
+			} else {
+				row = append(row, theme.Colors.Muted.Sprint("-"))
+			}
+		}
+		dayData = append(dayData, append(row, fmt.Sprintf("%d", d.Total)))
+	}
+	pterm.DefaultTable.WithHasHeader(true).WithBoxed(true).WithData(dayData).Render()
+	Spacer(theme.Spacing.XS, theme)
+
+	repoData := pterm.TableData{{"仓库 / 目录", "次数"}}
+	repos := countBy(entries, func(e HistoryEntry) string {
+		if e.GitRepo != "" {
+			return e.GitRepo
+		}
+		return e.Cwd
+	})
+	if len(repos) > 10 {
+		repos = repos[:10]
+	}
+	for _, c := range repos {
+		repoData = append(repoData, []string{shortenHome(c.Key), fmt.Sprintf("%d", c.Count)})
+	}
+	pterm.DefaultTable.WithHasHeader(true).WithBoxed(true).WithData(repoData).Render()
+	Spacer(theme.Spacing.XS, theme)
+}
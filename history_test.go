@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRedactArgs tests masking of the platform token and API key like arguments
+func TestRedactArgs(t *testing.T) {
+	token := "my-platform-token-123456"
+	args := []string{"-p", "use key " + token, "--append-system-prompt", "sk-ant-REDACTED", "--continue"}
+
+	redacted := redactArgs(args, []string{token})
+	joined := strings.Join(redacted, " ")
+	if strings.Contains(joined, token) || strings.Contains(joined, "abcdefghijklmnop") {
+		t.Errorf("Expected secrets to be redacted, got %q", joined)
+	}
+	if redacted[0] != "-p" || redacted[4] != "--continue" {
+		t.Errorf("Expected other arguments unchanged, got %v", redacted)
+	}
+	if args[1] != "use key "+token {
+		t.Error("Expected input slice to be left untouched")
+	}
+}
+
+// TestHistoryRoundTrip tests appending, loading and filtering launch records
+func TestHistoryRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	entries := []HistoryEntry{
+		{Time: day, Platform: "prod", Cwd: "/work/app"},
+		{Time: day.AddDate(0, 0, 1), Platform: "staging", Cwd: "/work/app/sub"},
+		{Time: day.AddDate(0, 0, 2), Platform: "prod", Cwd: "/work/application"},
+	}
+	for _, e := range entries {
+		if err := appendHistory(e); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(home, ".ccgate", "history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected history mode 0600, got %o", info.Mode().Perm())
+	}
+
+	loaded, err := loadHistory()
+	if err != nil || len(loaded) != 3 {
+		t.Fatalf("Expected 3 entries, got %d (%v)", len(loaded), err)
+	}
+
+	if got := filterHistory(loaded, historyFilter{Platform: "prod"}); len(got) != 2 {
+		t.Errorf("Expected 2 prod entries, got %d", len(got))
+	}
+	if got := filterHistory(loaded, historyFilter{Dir: "/work/app"}); len(got) != 2 {
+		t.Errorf("Expected directory filter to include subdirectories only, got %d", len(got))
+	}
+	since := time.Date(2025, 3, 11, 0, 0, 0, 0, time.Local)
+	until := since.AddDate(0, 0, 1)
+	if got := filterHistory(loaded, historyFilter{Since: since, Until: until}); len(got) != 1 || got[0].Platform != "staging" {
+		t.Errorf("Expected only the staging entry in the date range, got %+v", got)
+	}
+
+	last := lastUsedByPlatform(loaded)
+	if !last["prod"].Equal(day.AddDate(0, 0, 2)) {
+		t.Errorf("Expected prod last used on the third day, got %v", last["prod"])
+	}
+}
+
+// TestCountByDay tests per-day launch counts for each platform
+func TestCountByDay(t *testing.T) {
+	day := time.Date(2025, 3, 10, 9, 0, 0, 0, time.Local)
+	entries := []HistoryEntry{
+		{Time: day, Platform: "prod"},
+		{Time: day.Add(10 * time.Hour), Platform: "prod"},
+		{Time: day.Add(11 * time.Hour), Platform: "kimi"},
+		{Time: day.AddDate(0, 0, 2), Platform: "kimi"},
+	}
+
+	days := countByDay(entries)
+	if len(days) != 2 {
+		t.Fatalf("Expected 2 days, got %+v", days)
+	}
+	if d := days[0]; d.Day != "2025-03-12" || d.Total != 1 || d.Counts["kimi"] != 1 || d.Counts["prod"] != 0 {
+		t.Errorf("Expected the most recent day first with one kimi launch, got %+v", d)
+	}
+	if d := days[1]; d.Day != "2025-03-10" || d.Total != 3 || d.Counts["prod"] != 2 || d.Counts["kimi"] != 1 {
+		t.Errorf("Expected 2 prod and 1 kimi launches on 2025-03-10, got %+v", d)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultHookTimeout 未配置 timeout 时每个钩子的超时时间
+const defaultHookTimeout = 30 * time.Second
+
+// 钩子阶段，同时作为 CCGATE_HOOK 的值
+const (
+	hookPreLaunch  = "pre_launch"
+	hookPostLaunch = "post_launch"
+)
+
+// Hooks 启动前后执行的 shell 命令（通过 sh -c 执行）
+type Hooks struct {
+	// PreLaunch 启动 claude 前执行，任一命令失败都会中止启动
+	PreLaunch []string `json:"pre_launch,omitempty"`
+	// PostLaunch claude 退出后执行（需要托管模式），失败时只给出警告
+	PostLaunch []string `json:"post_launch,omitempty"`
+	// Timeout 每个命令的超时时间，如 "10s"、"2m"，默认 30s
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Validate 校验钩子配置
+func (h *Hooks) Validate() error {
+	if h == nil || h.Timeout == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(h.Timeout)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("hooks.timeout 无效: %s（示例: 10s、2m）", h.Timeout)
+	}
+	return nil
+}
+
+// launchHooks 启动时生效的钩子：全局钩子在前，平台钩子在后
+type launchHooks struct {
+	PreLaunch  []string      `json:"pre_launch,omitempty" yaml:"pre_launch,omitempty"`
+	PostLaunch []string      `json:"post_launch,omitempty" yaml:"post_launch,omitempty"`
+	Timeout    time.Duration `json:"-" yaml:"-"`
+}
+
+// resolveHooks 合并全局与平台钩子，超时时间优先使用平台配置
+// timeout 无效时返回错误，而不是静默使用默认值
+func resolveHooks(config *Config, platform *Platform) (launchHooks, error) {
+	if err := config.Hooks.Validate(); err != nil {
+		return launchHooks{}, NewConfigError("全局 "+err.Error(), "修改配置中的 hooks.timeout 后重试")
+	}
+	if err := platform.Hooks.Validate(); err != nil {
+		return launchHooks{}, NewConfigError(fmt.Sprintf("平台 %s 的 %v", platform.Name, err), "修改该平台的 hooks.timeout 后重试")
+	}
+
+	hooks := launchHooks{Timeout: defaultHookTimeout}
+	for _, h := range []*Hooks{config.Hooks, platform.Hooks} {
+		if h == nil {
+			continue
+		}
+		hooks.PreLaunch = append(hooks.PreLaunch, h.PreLaunch...)
+		hooks.PostLaunch = append(hooks.PostLaunch, h.PostLaunch...)
+		if h.Timeout != "" {
+			hooks.Timeout, _ = time.ParseDuration(h.Timeout)
+		}
+	}
+	return hooks, nil
+}
+
+// hookContextEnv 返回钩子额外的上下文环境变量
+func hookContextEnv(stage string, platform *Platform, claudeArgs []string) []string {
+	cwd, _ := os.Getwd()
+	return []string{
+		"CCGATE_HOOK=" + stage,
+		shellPlatformEnv + "=" + platform.Name,
+		"CCGATE_CWD=" + cwd,
+		"CCGATE_ARGS=" + shellJoin(claudeArgs),
+	}
+}
+
+// shellJoin 将参数以 shell 单引号转义后拼接，便于钩子中 eval
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// runHooks 依次执行钩子命令，遇到失败立即返回 UIError
+// 钩子的输出写到标准错误，避免混入 claude 的标准输出（如 claude -p 的结果）
+func runHooks(stage string, commands []string, env []string, timeout time.Duration) error {
+	for _, command := range commands {
+		if err := runHook(command, env, timeout); err != nil {
+			return NewUserError(
+				fmt.Sprintf("%s 钩子执行失败: %s（%v）", stage, command, err),
+				"检查配置中的 hooks 设置，或先手动运行该命令排查")
+		}
+	}
+	return nil
+}
+
+// runHook 执行单个钩子命令，超时后终止钩子进程（类 Unix 系统上为整个进程组）
+func runHook(command string, env []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = env
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	setHookCancel(cmd)
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("超过 %s 未完成", timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("退出码 %d", exitErr.ExitCode())
+	}
+	return err
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// setHookCancel 没有进程组时超时后只终止钩子进程本身
+func setHookCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestResolveHooks tests merging of global and platform hooks
+func TestResolveHooks(t *testing.T) {
+	config := &Config{Hooks: &Hooks{PreLaunch: []string{"global-pre"}, PostLaunch: []string{"global-post"}, Timeout: "5s"}}
+	platform := &Platform{Name: "prod", Hooks: &Hooks{PreLaunch: []string{"platform-pre"}, Timeout: "2s"}}
+
+	hooks, err := resolveHooks(config, platform)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(hooks.PreLaunch, ",") != "global-pre,platform-pre" {
+		t.Errorf("Expected global hooks before platform hooks, got %v", hooks.PreLaunch)
+	}
+	if hooks.Timeout != 2*time.Second {
+		t.Errorf("Expected platform timeout to win, got %v", hooks.Timeout)
+	}
+	if got, err := resolveHooks(&Config{}, &Platform{}); err != nil || got.Timeout != defaultHookTimeout || len(got.PreLaunch) != 0 {
+		t.Errorf("Expected default timeout and no hooks, got %+v (%v)", got, err)
+	}
+
+	if err := (&Hooks{Timeout: "soon"}).Validate(); err == nil {
+		t.Error("Expected error for invalid timeout, got nil")
+	}
+	if _, err := resolveHooks(&Config{}, &Platform{Name: "prod", Hooks: &Hooks{Timeout: "10"}}); err == nil || !strings.Contains(err.Error(), "prod") {
+		t.Errorf("Expected error naming the platform for a timeout without unit, got %v", err)
+	}
+	if _, err := resolveHooks(&Config{Hooks: &Hooks{Timeout: "-1s"}}, &Platform{}); err == nil {
+		t.Error("Expected error for invalid global timeout, got nil")
+	}
+}
+
+// TestRunHooks tests hook environment, failures and timeouts
+func TestRunHooks(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	platform := &Platform{Name: "prod"}
+	env := append([]string{"PATH=" + os.Getenv("PATH"), "ANTHROPIC_MODEL=m"},
+		hookContextEnv(hookPreLaunch, platform, []string{"-p", "it's"})...)
+
+	err := runHooks(hookPreLaunch, []string{`echo "$CCGATE_HOOK $CCGATE_PLATFORM $ANTHROPIC_MODEL $CCGATE_ARGS" > ` + out}, env, time.Second)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if got := strings.TrimSpace(string(data)); got != `pre_launch prod m '-p' 'it'\''s'` {
+		t.Errorf("Expected hook context in environment, got %q", got)
+	}
+
+	err = runHooks(hookPreLaunch, []string{"true", "exit 3", "touch " + out + ".never"}, env, time.Second)
+	var uiErr *UIError
+	if !errors.As(err, &uiErr) || !strings.Contains(uiErr.Message, "退出码 3") {
+		t.Errorf("Expected UIError with exit code, got %v", err)
+	}
+	if _, err := os.Stat(out + ".never"); err == nil {
+		t.Error("Expected hooks after a failure not to run")
+	}
+
+	start := time.Now()
+	err = runHooks(hookPreLaunch, []string{"sleep 5"}, env, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "超过") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the hook to be killed at the timeout, took %v", time.Since(start))
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// defaultAnthropicBaseURL 未设置 ANTHROPIC_BASE_URL 时 claude 使用的官方地址
+const defaultAnthropicBaseURL = "https://api.anthropic.com"
+
+var (
+	importFromSettings bool
+	importFromEnv      bool
+	importName         string
+)
+
+// import 子命令
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "导入平台包，或从 Claude Code 配置、环境变量导入平台",
+	Long: `从已有的配置中创建平台：
+
+  <bundle>                       导入 'ccgate export' 生成的平台包
+  --from-claude-settings [path]  读取 Claude Code settings.json 的 env 配置
+                                 （默认 ~/.claude/settings.json）
+  --from-env --name <name>       读取当前 shell 中的 ANTHROPIC_* 环境变量
+
+写入前会显示预览、处理名称冲突并校验配置。导入平台包时使用 --strategy
+指定名称冲突的处理方式（skip、overwrite、rename、prompt），使用 --dry-run
+仅查看导入计划。平台包中的 hooks 和 token_command 默认不导入，
+确认命令可信后使用 --allow-commands 导入。
+
+示例:
+  ccgate import team.ccgate --strategy rename
+  ccgate import prod.ccgate --dry-run
+  ccgate import --from-claude-settings
+  ccgate import --from-claude-settings ./.claude/settings.local.json --name work
+  ccgate import --from-env --name gateway`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var platform Platform
+		switch {
+		case importFromSettings && importFromEnv:
+			return fmt.Errorf("--from-claude-settings 与 --from-env 不能同时使用")
+		case importFromSettings:
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			}
+			p, err := platformFromClaudeSettings(path, importName)
+			if err != nil {
+				return err
+			}
+			platform = p
+		case importFromEnv:
+			if len(args) > 0 {
+				return fmt.Errorf("--from-env 不接受位置参数")
+			}
+			if importName == "" {
+				return NewUserError("--from-env 需要指定平台名称", "使用 --name <name> 指定导入后的平台名称")
+			}
+			platform = platformFromEnv(envMap(os.Environ()), importName)
+		case len(args) == 1:
+			return importBundle(args[0])
+		default:
+			return cmd.Help()
+		}
+
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+		return confirmAndImport(config, platform)
+	},
+}
+
+func init() {
+	importCmd.Flags().BoolVar(&importFromSettings, "from-claude-settings", false, "从 Claude Code settings.json 导入")
+	importCmd.Flags().BoolVar(&importFromEnv, "from-env", false, "从当前环境变量导入")
+	importCmd.Flags().StringVar(&importName, "name", "", "导入后的平台名称")
+	importCmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "跳过确认提示")
+	importCmd.Flags().StringVar(&importStrategy, "strategy", strategyPrompt, "平台包名称冲突的处理方式（skip|overwrite|rename|prompt）")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "仅显示平台包的导入计划，不写入配置")
+	importCmd.Flags().StringVar(&importPassphrase, "passphrase", "", "加密平台包的口令")
+	importCmd.Flags().BoolVar(&importAllowCommands, "allow-commands", false, "导入平台包中的 hooks 和 token_command（默认去除）")
+}
+
+// claudeSettingsPath 返回 Claude Code 用户级 settings.json 路径
+func claudeSettingsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".claude", "settings.json")
+	}
+	return filepath.Join(home, ".claude", "settings.json")
+}
+
+// platformFromClaudeSettings 从 settings.json 的 env 配置构建平台
+func platformFromClaudeSettings(path, name string) (Platform, error) {
+	if path == "" {
+		path = claudeSettingsPath()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Platform{}, NewConfigError(
+			fmt.Sprintf("无法读取 %s: %v", path, err),
+			"确认文件路径正确，或在命令后指定 settings.json 的路径")
+	}
+
+	var settings struct {
+		Env map[string]string `json:"env"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return Platform{}, NewConfigError(
+			fmt.Sprintf("%s 不是有效的 JSON: %v", path, err),
+			"修复该文件的格式后重试")
+	}
+	if !hasAnthropicEnv(settings.Env) {
+		return Platform{}, NewConfigError(
+			fmt.Sprintf("%s 的 env 中没有 ANTHROPIC_* 配置", path),
+			"确认该文件中配置了 ANTHROPIC_BASE_URL、ANTHROPIC_AUTH_TOKEN 等变量")
+	}
+
+	return platformFromEnv(settings.Env, name), nil
+}
+
+// platformFromEnv 从 ANTHROPIC_* 变量构建平台
+// 兼容 ANTHROPIC_API_KEY 和 ANTHROPIC_DEFAULT_HAIKU_MODEL 等替代变量
+func platformFromEnv(env map[string]string, name string) Platform {
+	platform := Platform{
+		Name:                name,
+		AnthropicBaseURL:    env["ANTHROPIC_BASE_URL"],
+		AnthropicAuthToken:  firstNonEmpty(env["ANTHROPIC_AUTH_TOKEN"], env["ANTHROPIC_API_KEY"]),
+		AnthropicModel:      env["ANTHROPIC_MODEL"],
+		AnthropicSmallModel: firstNonEmpty(env["ANTHROPIC_SMALL_FAST_MODEL"], env["ANTHROPIC_DEFAULT_HAIKU_MODEL"]),
+	}
+	if env["ANTHROPIC_AUTH_TOKEN"] == "" && env["ANTHROPIC_API_KEY"] != "" {
+		platform.AuthMode = authModeAPIKey
+	}
+	if platform.AnthropicBaseURL == "" {
+		platform.AnthropicBaseURL = defaultAnthropicBaseURL
+	}
+	if platform.Name == "" {
+		platform.Name = nameFromBaseURL(platform.AnthropicBaseURL)
+	}
+	return platform
+}
+
+// nameFromBaseURL 根据 API 域名生成默认平台名称，如 api.moonshot.cn → moonshot
+func nameFromBaseURL(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return "imported"
+	}
+	labels := strings.Split(u.Hostname(), ".")
+	for len(labels) > 2 && (labels[0] == "api" || labels[0] == "www") {
+		labels = labels[1:]
+	}
+	return labels[0]
+}
+
+// envMap 将 KEY=VALUE 列表转换为 map
+func envMap(environ []string) map[string]string {
+	m := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			m[key] = value
+		}
+	}
+	return m
+}
+
+// hasAnthropicEnv 判断是否存在任何 ANTHROPIC_* 变量
+func hasAnthropicEnv(env map[string]string) bool {
+	for key := range env {
+		if strings.HasPrefix(key, "ANTHROPIC_") {
+			return true
+		}
+	}
+	return false
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// confirmAndImport 显示预览、处理名称冲突、校验后写入配置
+func confirmAndImport(config *Config, platform Platform) error {
+	theme := DefaultTheme()
+
+	if err := platform.Validate(); err != nil {
+		return NewValidationError(
+			fmt.Sprintf("导入的配置不完整: %v", err),
+			"补充缺失的变量后重试，或使用 'ccgate add' 手动添加")
+	}
+
+	// 名称冲突：交互式选择覆盖、重命名或取消
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	for {
+		if _, err := findPlatformByName(config.Platforms, platform.Name); err != nil {
+			break
+		}
+		if !interactive {
+			return NewUserError(
+				fmt.Sprintf("平台 '%s' 已存在", platform.Name),
+				"使用 --name 指定其他名称")
+		}
+
+		choice, err := pterm.DefaultInteractiveSelect.
+			WithOptions([]string{"重命名", "覆盖现有平台", "取消"}).
+			WithDefaultText(fmt.Sprintf("平台 '%s' 已存在", platform.Name)).
+			Show()
+		if err != nil || choice == "取消" {
+			DisplayWarning("已取消导入", theme)
+			return nil
+		}
+		if choice == "覆盖现有平台" {
+			break
+		}
+
+		name, err := pterm.DefaultInteractiveTextInput.
+			WithDefaultText("请输入新的平台名称").
+			Show()
+		if err != nil {
+			return fmt.Errorf("获取平台名称失败: %w", err)
+		}
+		if strings.TrimSpace(name) != "" {
+			platform.Name = strings.TrimSpace(name)
+		}
+	}
+
+	printPlatformPreview(&platform, theme)
+
+	if !skipConfirm {
+		if !interactive {
+			return NewUserError("当前环境无法确认导入", "确认预览无误后使用 --yes 跳过确认")
+		}
+		ok, err := pterm.DefaultInteractiveConfirm.
+			WithDefaultText("确认导入?").
+			WithDefaultValue(true).
+			Show()
+		if err != nil || !ok {
+			DisplayWarning("已取消导入", theme)
+			return nil
+		}
+	}
+
+	config.Platforms = updateOrAddPlatform(config.Platforms, platform)
+	if err := saveConfig(config, cfgFile); err != nil {
+		return err
+	}
+	DisplaySuccess(fmt.Sprintf("平台 '%s' 导入成功", platform.Name), theme)
+	return nil
+}
+
+// printPlatformPreview 以表格形式预览平台配置（令牌已掩码）
+func printPlatformPreview(platform *Platform, theme *Theme) {
+	pterm.DefaultSection.Println(theme.Colors.Secondary.Sprint("📋 导入预览"))
+	renderPlatformTable(platform, false, theme)
+}
+
+// renderPlatformTable 渲染平台配置表格，令牌和 env 中的机密默认掩码，reveal 为 true 时显示完整值
+func renderPlatformTable(platform *Platform, reveal bool, theme *Theme) {
+	token, env := maskToken(platform.AnthropicAuthToken), maskSecretEnv(platform.Env)
+	if reveal {
+		token, env = platform.AnthropicAuthToken, platform.Env
+	}
+	tableData := pterm.TableData{
+		{"名称", theme.Colors.Primary.Sprint(platform.Name)},
+	}
+	if platform.Vendor != "" {
+		tableData = append(tableData, []string{"厂商", platform.Vendor})
+	}
+	tableData = append(tableData,
+		[]string{"Base URL", platform.AnthropicBaseURL},
+		[]string{"令牌", token},
+		[]string{"模型", platform.AnthropicModel},
+	)
+	if platform.AnthropicSmallModel != "" {
+		tableData = append(tableData, []string{"快速模型", theme.Colors.Info.Sprint(platform.AnthropicSmallModel)})
+	}
+	if platform.AuthMode != "" {
+		tableData = append(tableData, []string{"令牌传递", platform.tokenEnvKey()})
+	}
+	if platform.EnvPolicy != "" {
+		tableData = append(tableData, []string{"环境策略", platform.EnvPolicy})
+	}
+	if platform.TokenCommand != "" {
+		command := platform.TokenCommand
+		if platform.TokenTTL != "" {
+			command += theme.Colors.Muted.Sprintf("（缓存 %s）", platform.TokenTTL)
+		}
+		tableData = append(tableData, []string{"令牌命令", command})
+	}
+	if limits := platform.sessionLimits(); limits.enabled() {
+		tableData = append(tableData, []string{"会话限制", limits.String()})
+	}
+	if len(platform.Fallback) > 0 {
+		tableData = append(tableData, []string{"备用平台", strings.Join(platform.Fallback, ", ")})
+	}
+	for _, key := range sortedKeys(env) {
+		tableData = append(tableData, []string{"环境变量", key + "=" + env[key]})
+	}
+
+	pterm.DefaultTable.WithHasHeader(false).
+		WithBoxed(true).
+		WithData(tableData).
+		Render()
+	Spacer(theme.Spacing.XS, theme)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestPlatformFromClaudeSettings tests building a platform from a settings.json env block
+func TestPlatformFromClaudeSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	settings := `{
+  "permissions": {"allow": []},
+  "env": {
+    "ANTHROPIC_BASE_URL": "https://api.moonshot.cn/anthropic",
+    "ANTHROPIC_API_KEY": "sk-test-token",
+    "ANTHROPIC_MODEL": "kimi-k2",
+    "ANTHROPIC_DEFAULT_HAIKU_MODEL": "kimi-k2-turbo"
+  }
+}`
+	if err := os.WriteFile(path, []byte(settings), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	platform, err := platformFromClaudeSettings(path, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if platform.Name != "moonshot" {
+		t.Errorf("Expected derived name moonshot, got %s", platform.Name)
+	}
+	if platform.AnthropicAuthToken != "sk-test-token" {
+		t.Errorf("Expected token from ANTHROPIC_API_KEY, got %s", platform.AnthropicAuthToken)
+	}
+	if platform.AnthropicSmallModel != "kimi-k2-turbo" {
+		t.Errorf("Expected small model from ANTHROPIC_DEFAULT_HAIKU_MODEL, got %s", platform.AnthropicSmallModel)
+	}
+	if err := platform.Validate(); err != nil {
+		t.Errorf("Expected valid platform, got %v", err)
+	}
+
+	// 没有 ANTHROPIC_* 配置时应报错
+	if err := os.WriteFile(path, []byte(`{"env": {"FOO": "bar"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := platformFromClaudeSettings(path, "x"); err == nil {
+		t.Error("Expected error for settings without ANTHROPIC_* env, got nil")
+	}
+}
+
+// TestPlatformFromEnv tests defaults when importing from environment variables
+func TestPlatformFromEnv(t *testing.T) {
+	platform := platformFromEnv(envMap([]string{
+		"ANTHROPIC_AUTH_TOKEN=token",
+		"ANTHROPIC_MODEL=claude-sonnet-4-20250514",
+		"PATH=/usr/bin",
+	}), "official")
+
+	if platform.AnthropicBaseURL != defaultAnthropicBaseURL {
+		t.Errorf("Expected default base URL, got %s", platform.AnthropicBaseURL)
+	}
+	if platform.Name != "official" {
+		t.Errorf("Expected name official, got %s", platform.Name)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// 会话因时长限制结束的原因，写入启动记录
+const (
+	endReasonMaxSession  = "max_session"
+	endReasonIdleTimeout = "idle_timeout"
+)
+
+const (
+	// limitWarningLead 到达限制前多久发出警告（限制较短时为其一半）
+	limitWarningLead = time.Minute
+	// limitInterruptGap 两次 SIGINT 的间隔：交互式 claude 需要连续两次 Ctrl+C 才会退出
+	limitInterruptGap = time.Second
+	// limitKillGrace 发送 SIGINT 后仍未退出时，再等待多久发送 SIGTERM
+	limitKillGrace = 10 * time.Second
+)
+
+// sessionLimits 平台的会话时长限制，0 表示不限制
+type sessionLimits struct {
+	// MaxSession 会话的最长时长
+	MaxSession time.Duration
+	// IdleTimeout 没有输入也没有输出的最长时间，仅在终端中交互运行时生效
+	IdleTimeout time.Duration
+}
+
+// enabled 是否设置了任一限制
+func (l sessionLimits) enabled() bool {
+	return l.MaxSession > 0 || l.IdleTimeout > 0
+}
+
+// String 返回限制的简要说明，如 "max_session 2h0m0s，idle_timeout 30m0s"
+func (l sessionLimits) String() string {
+	var parts []string
+	if l.MaxSession > 0 {
+		parts = append(parts, "max_session "+l.MaxSession.String())
+	}
+	if l.IdleTimeout > 0 {
+		parts = append(parts, "idle_timeout "+l.IdleTimeout.String())
+	}
+	return strings.Join(parts, "，")
+}
+
+// sessionLimits 返回平台的会话时长限制，配置已在 Validate 中校验
+func (p *Platform) sessionLimits() sessionLimits {
+	var l sessionLimits
+	l.MaxSession, _ = time.ParseDuration(p.MaxSession)
+	l.IdleTimeout, _ = time.ParseDuration(p.IdleTimeout)
+	return l
+}
+
+// validateLimitDuration 校验 max_session、idle_timeout 等时长配置
+func validateLimitDuration(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		return fmt.Errorf("%s 无效: %s（示例: 2h、30m）", field, value)
+	}
+	return nil
+}
+
+// sessionWatchdog 托管模式下监控会话时长和空闲时间，到达限制时中断 claude
+type sessionWatchdog struct {
+	limits sessionLimits
+	// tick 检查间隔，warn 输出警告，测试中可替换
+	tick time.Duration
+	warn func(string)
+
+	start        time.Time
+	lastActivity atomic.Int64
+
+	mu     sync.Mutex
+	reason string
+}
+
+// newSessionWatchdog 创建会话监控，未设置限制时返回 nil
+func newSessionWatchdog(limits sessionLimits) *sessionWatchdog {
+	if !limits.enabled() {
+		return nil
+	}
+	return &sessionWatchdog{
+		limits: limits,
+		tick:   time.Second,
+		warn: func(msg string) {
+			// claude 占用终端（可能处于原始模式），因此单独成行并使用 \r\n
+			fmt.Fprintf(os.Stderr, "\r\n⚠ ccgate: %s\r\n", msg)
+		},
+	}
+}
+
+// touch 记录一次输入或输出
+func (w *sessionWatchdog) touch() {
+	if w != nil {
+		w.lastActivity.Store(time.Now().UnixNano())
+	}
+}
+
+// activityWriter 写入时记录活动，用于统计 pty 中的输入
+type activityWriter struct {
+	io.Writer
+	watchdog *sessionWatchdog
+}
+
+func (a activityWriter) Write(p []byte) (int, error) {
+	a.watchdog.touch()
+	return a.Writer.Write(p)
+}
+
+// Reason 返回会话被中断的原因，未被中断时为空
+func (w *sessionWatchdog) Reason() string {
+	if w == nil {
+		return ""
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reason
+}
+
+// watch 开始监控，到达限制时通过 kill 中断子进程；返回的函数在子进程退出后调用以停止监控
+func (w *sessionWatchdog) watch(kill func(syscall.Signal)) (stop func()) {
+	if w == nil {
+		return func() {}
+	}
+	w.start = time.Now()
+	w.touch()
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(w.tick)
+		defer ticker.Stop()
+		var warnedMax, warnedIdle bool
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if reason := w.check(now, &warnedMax, &warnedIdle); reason != "" {
+					w.mu.Lock()
+					w.reason = reason
+					w.mu.Unlock()
+					w.terminate(kill, done)
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
+
+// check 检查是否需要警告或中断，返回中断原因
+func (w *sessionWatchdog) check(now time.Time, warnedMax, warnedIdle *bool) string {
+	if limit := w.limits.MaxSession; limit > 0 {
+		elapsed := now.Sub(w.start)
+		if elapsed >= limit {
+			w.warn(fmt.Sprintf("会话已达到 max_session（%s），正在结束 claude", limit))
+			return endReasonMaxSession
+		}
+		if !*warnedMax && elapsed >= limit-warningLead(limit) {
+			*warnedMax = true
+			w.warn(fmt.Sprintf("会话将在 %s 后达到 max_session（%s）并结束", roundUp(limit-elapsed), limit))
+		}
+	}
+
+	if idle := w.limits.IdleTimeout; idle > 0 {
+		idleFor := now.Sub(time.Unix(0, w.lastActivity.Load()))
+		switch {
+		case idleFor >= idle:
+			w.warn(fmt.Sprintf("已空闲 %s（idle_timeout），正在结束 claude", idle))
+			return endReasonIdleTimeout
+		case idleFor >= idle-warningLead(idle):
+			if !*warnedIdle {
+				*warnedIdle = true
+				w.warn(fmt.Sprintf("已空闲 %s，%s 内没有操作将结束会话（idle_timeout）", roundUp(idleFor), roundUp(idle-idleFor)))
+			}
+		default:
+			// 有新的活动后重新计算，再次接近空闲限制时再警告
+			*warnedIdle = false
+		}
+	}
+	return ""
+}
+
+// terminate 依次发送 SIGINT、SIGINT、SIGTERM，子进程退出（done 关闭）后停止
+func (w *sessionWatchdog) terminate(kill func(syscall.Signal), done <-chan struct{}) {
+	steps := []struct {
+		sig  syscall.Signal
+		wait time.Duration
+	}{
+		{syscall.SIGINT, limitInterruptGap},
+		{syscall.SIGINT, limitKillGrace},
+		{syscall.SIGTERM, 0},
+	}
+	for _, step := range steps {
+		kill(step.sig)
+		select {
+		case <-done:
+			return
+		case <-time.After(step.wait):
+		}
+	}
+}
+
+// warningLead 返回到达限制前多久警告
+func warningLead(limit time.Duration) time.Duration {
+	if limit/2 < limitWarningLead {
+		return limit / 2
+	}
+	return limitWarningLead
+}
+
+// roundUp 将时长向上取整到秒，用于提示
+func roundUp(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return ((d + time.Second - 1) / time.Second) * time.Second
+}
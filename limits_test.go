@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPlatformSessionLimits tests parsing and validation of max_session and idle_timeout
+func TestPlatformSessionLimits(t *testing.T) {
+	p := Platform{Name: "trial", AnthropicBaseURL: "https://x", AnthropicAuthToken: "t", AnthropicModel: "m",
+		MaxSession: "2h", IdleTimeout: "15m"}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	limits := p.sessionLimits()
+	if limits.MaxSession != 2*time.Hour || limits.IdleTimeout != 15*time.Minute || limits.String() != "max_session 2h0m0s，idle_timeout 15m0s" {
+		t.Errorf("Unexpected limits %+v (%s)", limits, limits)
+	}
+
+	req := mustLaunchRequest(t, &Config{}, &Resolution{Platform: &p, Source: SourceFlag}, nil)
+	if !req.Supervise || req.Limits != limits {
+		t.Errorf("Expected limits to force supervised mode, got %+v", req)
+	}
+
+	for _, bad := range []Platform{
+		{Name: "a", AnthropicBaseURL: "https://x", AnthropicAuthToken: "t", AnthropicModel: "m", MaxSession: "forever"},
+		{Name: "a", AnthropicBaseURL: "https://x", AnthropicAuthToken: "t", AnthropicModel: "m", IdleTimeout: "-1m"},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Expected error for %q/%q, got nil", bad.MaxSession, bad.IdleTimeout)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+//go:build unix
+
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testWatchdog 创建检查间隔较短并记录警告的会话监控
+func testWatchdog(limits sessionLimits) (*sessionWatchdog, func() []string) {
+	var mu sync.Mutex
+	var warnings []string
+	w := newSessionWatchdog(limits)
+	w.tick = 20 * time.Millisecond
+	w.warn = func(msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		warnings = append(warnings, msg)
+	}
+	return w, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), warnings...)
+	}
+}
+
+// TestSuperviseProcessMaxSession tests warning and interrupting a fake claude at max_session
+func TestSuperviseProcessMaxSession(t *testing.T) {
+	path := writeFakeClaude(t, `trap 'exit 130' INT; while :; do sleep 0.05; done`)
+	watchdog, warnings := testWatchdog(sessionLimits{MaxSession: 400 * time.Millisecond})
+
+	status, err := superviseProcess(path, []string{"claude"}, nil, watchdog)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.ExitCode() != 130 || watchdog.Reason() != endReasonMaxSession {
+		t.Errorf("Expected interrupt by max_session, got %+v (reason %q)", status, watchdog.Reason())
+	}
+	if status.Duration < 400*time.Millisecond || status.Duration > 2*time.Second {
+		t.Errorf("Expected the session to end shortly after 400ms, got %v", status.Duration)
+	}
+	got := warnings()
+	if len(got) != 2 || !strings.Contains(got[0], "后达到 max_session") || !strings.Contains(got[1], "正在结束") {
+		t.Errorf("Expected a warning before the limit and one at the limit, got %q", got)
+	}
+}
+
+// TestSuperviseProcessDoubleInterrupt tests that a second SIGINT is sent to a claude that ignores the first one
+func TestSuperviseProcessDoubleInterrupt(t *testing.T) {
+	path := writeFakeClaude(t, `n=0; trap 'n=$((n+1)); [ $n -ge 2 ] && exit 7' INT; while :; do sleep 0.05; done`)
+	watchdog, _ := testWatchdog(sessionLimits{MaxSession: 100 * time.Millisecond})
+
+	status, err := superviseProcess(path, []string{"claude"}, nil, watchdog)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.Code != 7 {
+		t.Errorf("Expected exit after the second SIGINT, got %+v", status)
+	}
+}
+
+// TestPtyProcessIdleTimeout tests that output keeps the session alive and silence ends it
+func TestPtyProcessIdleTimeout(t *testing.T) {
+	path := writeFakeClaude(t, `trap 'exit 130' INT
+for i in 1 2 3 4 5 6; do echo working; sleep 0.1; done
+while :; do sleep 0.05; done`)
+	watchdog, warnings := testWatchdog(sessionLimits{IdleTimeout: 300 * time.Millisecond})
+
+	status, err := ptyProcess(path, []string{"claude"}, nil, nil, watchdog)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.ExitCode() != 130 || watchdog.Reason() != endReasonIdleTimeout {
+		t.Errorf("Expected interrupt by idle_timeout, got %+v (reason %q)", status, watchdog.Reason())
+	}
+	if status.Duration < 800*time.Millisecond {
+		t.Errorf("Expected output to postpone the idle timeout, ended after %v", status.Duration)
+	}
+	if got := warnings(); len(got) == 0 || !strings.Contains(got[0], "idle_timeout") {
+		t.Errorf("Expected an idle warning, got %q", got)
+	}
+}
+
+// TestSessionWatchdogNoLimit tests that a session ending normally has no end reason
+func TestSessionWatchdogNoLimit(t *testing.T) {
+	if newSessionWatchdog(sessionLimits{}) != nil {
+		t.Error("Expected no watchdog without limits")
+	}
+
+	path := writeFakeClaude(t, `exit 0`)
+	watchdog, warnings := testWatchdog(sessionLimits{MaxSession: time.Hour})
+	status, err := superviseProcess(path, []string{"claude"}, nil, watchdog)
+	if err != nil || status.ExitCode() != 0 || watchdog.Reason() != "" || len(warnings()) != 0 {
+		t.Errorf("Expected a normal exit, got %+v (err %v, reason %q, warnings %q)", status, err, watchdog.Reason(), warnings())
+	}
+}
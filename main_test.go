@@ -1,6 +1,8 @@
 package main
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -124,3 +126,73 @@ func TestFindPlatformByName(t *testing.T) {
 		t.Error("Expected error for non-existing platform, got nil")
 	}
 }
+
+// TestDeletePrunesFallback tests that a deleted platform is removed from every fallback list
+func TestDeletePrunesFallback(t *testing.T) {
+	platform := func(name string, fallback ...string) Platform {
+		return Platform{Name: name, AnthropicBaseURL: "https://x", AnthropicAuthToken: "t", AnthropicModel: "m", Fallback: fallback}
+	}
+	config := &Config{Platforms: []Platform{platform("prod", "kimi", "glm"), platform("dev", "kimi"), platform("glm"), platform("kimi")}}
+
+	platforms, err := deletePlatform(config.Platforms, "kimi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Platforms = platforms
+	removed := removePlatformReferences(config, "kimi")
+
+	if want := []string{"平台 prod 的备用平台", "平台 dev 的备用平台"}; strings.Join(removed, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected removed %v, got %v", want, removed)
+	}
+	if got := config.Platforms[0].Fallback; len(got) != 1 || got[0] != "glm" {
+		t.Errorf("Expected prod fallback [glm], got %v", got)
+	}
+	if got := config.Platforms[1].Fallback; got != nil {
+		t.Errorf("Expected dev fallback to be cleared, got %v", got)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected a valid config after delete, got %v", err)
+	}
+}
+
+// TestDeleteRemovesReferences tests that deleting a platform leaves no dangling default, directory or rule
+func TestDeleteRemovesReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	platform := func(name string) Platform {
+		return Platform{Name: name, AnthropicBaseURL: "https://x", AnthropicAuthToken: "t", AnthropicModel: "m"}
+	}
+	config := &Config{
+		Platforms:   []Platform{platform("a"), platform("b")},
+		Default:     "b",
+		Directories: map[string]string{"/w/a": "a", "/w/b": "b"},
+		Rules: []Rule{
+			{Dir: "~/work/b", Platform: "b"},
+			{Remote: "*a*", Platform: "a"},
+			{Branch: "release/*", Platform: "b"},
+		},
+	}
+	if err := saveConfig(config, path); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, code := runCCGate(t, "delete", "-f", path, "b")
+	if code != 0 {
+		t.Fatalf("Expected delete to succeed, got %d: %s", code, stderr)
+	}
+	for _, want := range []string{"默认平台", "目录绑定 /w/b", "规则 #1（dir=~/work/b）", "规则 #3（branch=release/*）"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in delete output, got %q", want, stdout)
+		}
+	}
+
+	saved, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saved.Validate(); err != nil {
+		t.Errorf("Expected a valid config after delete, got %v", err)
+	}
+	if saved.Default != "" || len(saved.Directories) != 1 || len(saved.Rules) != 1 || saved.Rules[0].Platform != "a" {
+		t.Errorf("Expected references to b to be removed, got %+v", saved)
+	}
+}
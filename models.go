@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// modelsTimeout 获取模型列表的超时时间
+const modelsTimeout = 10 * time.Second
+
+// models 子命令
+var modelsCmd = &cobra.Command{
+	Use:   "models [name]",
+	Short: "列出平台支持的模型",
+	Long: `查询平台的 /v1/models 接口（不支持时回退到 OpenAI 风格的 /models）
+并列出可用的模型 ID，当前配置使用的模型会被标记出来。
+
+示例:
+  ccgate models prod`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		var platform *Platform
+		if len(args) == 1 {
+			platform, err = findPlatformByName(config.Platforms, args[0])
+			if err != nil {
+				return unknownPlatformError(config.Platforms, args[0], err)
+			}
+		} else {
+			res, err := selectPlatform(config, "", nil, true)
+			if err != nil {
+				return err
+			}
+			platform = res.Platform
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), modelsTimeout)
+		defer cancel()
+
+		models, uiErr := fetchModels(ctx, &http.Client{}, platform)
+		if uiErr != nil {
+			uiErr.DisplayError(DefaultTheme())
+			return fmt.Errorf("获取平台 '%s' 的模型列表失败", platform.Name)
+		}
+
+		printModels(platform, models)
+		return nil
+	},
+}
+
+// fetchModels 获取平台支持的模型 ID 列表
+// 优先使用 Anthropic 的 /v1/models，不存在时回退到 OpenAI 风格的 /models
+func fetchModels(ctx context.Context, client *http.Client, platform *Platform) ([]string, *UIError) {
+	if uiErr := ensurePlatformToken(platform); uiErr != nil {
+		return nil, uiErr
+	}
+	base := strings.TrimRight(platform.AnthropicBaseURL, "/")
+
+	var lastErr *UIError
+	for _, path := range []string{"/v1/models", "/models"} {
+		models, status, uiErr := requestModels(ctx, client, base+path, platform)
+		if uiErr == nil {
+			return models, nil
+		}
+		lastErr = uiErr
+
+		// 只有接口不存在时才尝试下一个路径
+		if status != http.StatusNotFound && status != http.StatusMethodNotAllowed {
+			return nil, uiErr
+		}
+	}
+
+	lastErr.Message = "该平台不支持模型列表接口（/v1/models 和 /models 均不存在）"
+	lastErr.Recovery = "请参考平台文档手动填写模型名称"
+	return nil, lastErr
+}
+
+// requestModels 请求单个模型列表接口，返回模型 ID 和 HTTP 状态码
+func requestModels(ctx context.Context, client *http.Client, endpoint string, platform *Platform) ([]string, int, *UIError) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, NewConfigError(
+			fmt.Sprintf("ANTHROPIC_BASE_URL 无效: %v", err),
+			"检查 ANTHROPIC_BASE_URL 格式，例如 https://api.anthropic.com")
+	}
+	setAnthropicHeaders(req, platform.AnthropicAuthToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, classifyTransportError(err, platform.AnthropicBaseURL)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, resp.StatusCode, classifyHTTPStatus(resp.StatusCode, apiErrorMessage(data), platform)
+	}
+
+	// Anthropic 与 OpenAI 的模型列表都使用 {"data": [{"id": ...}]} 结构
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, resp.StatusCode, NewConfigError(
+			fmt.Sprintf("无法解析模型列表响应: %v", err),
+			"该平台的模型列表格式不受支持，请手动填写模型名称")
+	}
+
+	models := make([]string, 0, len(body.Data))
+	for _, m := range body.Data {
+		if m.ID != "" {
+			models = append(models, m.ID)
+		}
+	}
+	sort.Strings(models)
+	return models, resp.StatusCode, nil
+}
+
+// printModels 输出模型列表，标记平台当前使用的模型
+func printModels(platform *Platform, models []string) {
+	theme := DefaultTheme()
+
+	title := fmt.Sprintf("平台 %s 的可用模型 (%d)", platform.Name, len(models))
+	pterm.Info.Printf("%s\n", theme.Colors.Primary.Sprint(title))
+	Spacer(theme.Spacing.XS, theme)
+
+	for _, id := range models {
+		var marks []string
+		if id == platform.AnthropicModel {
+			marks = append(marks, "ANTHROPIC_MODEL")
+		}
+		if id == platform.AnthropicSmallModel {
+			marks = append(marks, "ANTHROPIC_SMALL_FAST_MODEL")
+		}
+		if len(marks) > 0 {
+			pterm.Printf("  %s %s\n", theme.Colors.Success.Sprint(id),
+				theme.Colors.Muted.Sprintf("← %s", strings.Join(marks, ", ")))
+		} else {
+			pterm.Printf("  %s\n", id)
+		}
+	}
+	Spacer(theme.Spacing.XS, theme)
+}
+
+// discoverModels 在添加平台时尝试获取模型列表，失败时给出提示并返回 nil
+func discoverModels(platform *Platform) []string {
+	theme := DefaultTheme()
+
+	spinner, _ := pterm.DefaultSpinner.Start("正在获取模型列表...")
+	ctx, cancel := context.WithTimeout(context.Background(), modelsTimeout)
+	defer cancel()
+	models, uiErr := fetchModels(ctx, &http.Client{}, platform)
+	if spinner != nil {
+		spinner.Stop()
+	}
+
+	if uiErr != nil {
+		DisplayWarning(fmt.Sprintf("无法获取模型列表（%s），请手动输入", uiErr.Message), theme)
+		return nil
+	}
+	if len(models) == 0 {
+		DisplayWarning("平台返回的模型列表为空，请手动输入", theme)
+		return nil
+	}
+	return models
+}
+
+// 模型选择器中的特殊选项
+const (
+	modelOptionManual = "✏️  手动输入..."
+	modelOptionSkip   = "⏭  跳过"
+)
+
+// pickModel 从模型列表中交互式选择（支持模糊搜索）
+// 选择“手动输入”时返回空字符串和 false；allowSkip 时选择“跳过”返回空字符串和 true
+func pickModel(models []string, prompt string, allowSkip bool) (string, bool, error) {
+	options := make([]string, 0, len(models)+2)
+	options = append(options, models...)
+	if allowSkip {
+		options = append(options, modelOptionSkip)
+	}
+	options = append(options, modelOptionManual)
+
+	selected, err := pterm.DefaultInteractiveSelect.
+		WithOptions(options).
+		WithDefaultText(prompt).
+		WithFilter(true).
+		WithMaxHeight(15).
+		Show()
+	if err != nil {
+		return "", false, err
+	}
+
+	switch selected {
+	case modelOptionManual:
+		return "", false, nil
+	case modelOptionSkip:
+		return "", true, nil
+	default:
+		return selected, true, nil
+	}
+}
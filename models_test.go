@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestFetchModels tests model discovery with the OpenAI-style /models fallback
+func TestFetchModels(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/models", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"object":"list","data":[{"id":"kimi-k2"},{"id":"kimi-k2-turbo"}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	platform := &Platform{Name: "test", AnthropicBaseURL: server.URL, AnthropicAuthToken: "t"}
+	models, uiErr := fetchModels(context.Background(), server.Client(), platform)
+	if uiErr != nil {
+		t.Fatalf("Expected no error, got %v", uiErr.Message)
+	}
+	if want := []string{"kimi-k2", "kimi-k2-turbo"}; !reflect.DeepEqual(models, want) {
+		t.Errorf("Expected %v, got %v", want, models)
+	}
+
+	// 两个接口都不存在时应返回配置错误
+	empty := httptest.NewServer(http.NotFoundHandler())
+	defer empty.Close()
+
+	platform.AnthropicBaseURL = empty.URL
+	if _, uiErr := fetchModels(context.Background(), empty.Client(), platform); uiErr == nil || uiErr.Type != ErrorTypeConfig {
+		t.Errorf("Expected config error for unsupported endpoint, got %+v", uiErr)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 全局 --output 支持的格式，空字符串表示默认的终端输出
+const (
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+var outputFormat string
+
+// validateOutputFormat 校验全局 --output 参数
+func validateOutputFormat() error {
+	switch outputFormat {
+	case "", outputJSON, outputYAML:
+		return nil
+	default:
+		return NewValidationError(
+			fmt.Sprintf("不支持的输出格式: %s", outputFormat),
+			"--output 可选 json 或 yaml，省略时输出终端格式")
+	}
+}
+
+// structuredOutput 是否以 JSON/YAML 输出（此时标准输出只包含结构化数据）
+func structuredOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// writeStructured 按 --output 格式将 v 写到标准输出
+func writeStructured(v any) error {
+	switch outputFormat {
+	case outputYAML:
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("序列化输出失败: %w", err)
+		}
+		return enc.Close()
+	default:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("序列化输出失败: %w", err)
+		}
+		return nil
+	}
+}
+
+// platformOutput 平台的结构化输出（list、show），令牌和 env 中的机密默认掩码
+type platformOutput struct {
+	Name       string            `json:"name" yaml:"name"`
+	Vendor     string            `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	BaseURL    string            `json:"base_url" yaml:"base_url"`
+	Token      string            `json:"token" yaml:"token"`
+	AuthMode   string            `json:"auth_mode" yaml:"auth_mode"`
+	Model      string            `json:"model" yaml:"model"`
+	SmallModel string            `json:"small_model,omitempty" yaml:"small_model,omitempty"`
+	Env        map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	// TokenCommand、TokenTTL 动态令牌的获取命令和缓存时长
+	TokenCommand string   `json:"token_command,omitempty" yaml:"token_command,omitempty"`
+	TokenTTL     string   `json:"token_ttl,omitempty" yaml:"token_ttl,omitempty"`
+	EnvPolicy    string   `json:"env_policy,omitempty" yaml:"env_policy,omitempty"`
+	Fallback     []string `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+	MaxSession   string   `json:"max_session,omitempty" yaml:"max_session,omitempty"`
+	IdleTimeout  string   `json:"idle_timeout,omitempty" yaml:"idle_timeout,omitempty"`
+	Default      bool     `json:"default" yaml:"default"`
+}
+
+// newPlatformOutput 构建平台的结构化输出，reveal 为 true 时输出完整令牌和 env 值
+func newPlatformOutput(p *Platform, config *Config, reveal bool) platformOutput {
+	token, env := maskToken(p.AnthropicAuthToken), maskSecretEnv(p.Env)
+	if reveal {
+		token, env = p.AnthropicAuthToken, p.Env
+	}
+	authMode := p.AuthMode
+	if authMode == "" {
+		authMode = authModeToken
+	}
+	return platformOutput{
+		Name:         p.Name,
+		Vendor:       p.Vendor,
+		BaseURL:      p.AnthropicBaseURL,
+		Token:        token,
+		AuthMode:     authMode,
+		Model:        p.AnthropicModel,
+		SmallModel:   p.AnthropicSmallModel,
+		Env:          env,
+		TokenCommand: p.TokenCommand,
+		TokenTTL:     p.TokenTTL,
+		EnvPolicy:    p.EnvPolicy,
+		Fallback:     p.Fallback,
+		MaxSession:   p.MaxSession,
+		IdleTimeout:  p.IdleTimeout,
+		Default:      config.Default == p.Name,
+	}
+}
+
+// maskSecretEnv 返回 env 的副本，名称包含 KEY、TOKEN、SECRET 的值与令牌一样掩码
+func maskSecretEnv(env map[string]string) map[string]string {
+	if len(env) == 0 {
+		return env
+	}
+	masked := make(map[string]string, len(env))
+	for key, value := range env {
+		if isSecretEnvKey(key) {
+			value = maskToken(value)
+		}
+		masked[key] = value
+	}
+	return masked
+}
+
+// versionOutput version 命令的结构化输出
+type versionOutput struct {
+	Version   string `json:"version" yaml:"version"`
+	Commit    string `json:"commit" yaml:"commit"`
+	BuildDate string `json:"build_date" yaml:"build_date"`
+	GoVersion string `json:"go_version" yaml:"go_version"`
+	Platform  string `json:"platform" yaml:"platform"`
+}
+
+// newVersionOutput 返回当前构建的版本信息
+func newVersionOutput() versionOutput {
+	return versionOutput{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+// dryRunOutput dry-run 的结构化输出，终端格式也基于它渲染
+type dryRunOutput struct {
+	Platform string `json:"platform" yaml:"platform"`
+	Vendor   string `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	// Source 平台的选择依据（flag、env、directory、default、single、interactive）
+	Source string `json:"source" yaml:"source"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	// Env 将设置的环境变量，令牌已掩码
+	Env     map[string]string `json:"env" yaml:"env"`
+	Command []string          `json:"command" yaml:"command"`
+	// Supervise 是否以托管模式启动，Record 是否录制会话
+	Supervise bool        `json:"supervise" yaml:"supervise"`
+	Record    bool        `json:"record" yaml:"record"`
+	Hooks     launchHooks `json:"hooks" yaml:"hooks"`
+	// EnvPolicy 环境变量策略，DroppedEnv 为 allowlist 模式下不会传递给 claude 的变量名
+	EnvPolicy  string   `json:"env_policy" yaml:"env_policy"`
+	DroppedEnv []string `json:"dropped_env,omitempty" yaml:"dropped_env,omitempty"`
+	// Fallback 备用平台，dry-run 不做健康检查
+	Fallback []string `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+	// Rule 按规则选择平台时命中的规则
+	Rule *Rule `json:"rule,omitempty" yaml:"rule,omitempty"`
+	// MaxSession、IdleTimeout 托管模式下的会话限制
+	MaxSession  string `json:"max_session,omitempty" yaml:"max_session,omitempty"`
+	IdleTimeout string `json:"idle_timeout,omitempty" yaml:"idle_timeout,omitempty"`
+}
+
+// newDryRunOutput 根据启动请求构建 dry-run 输出
+func newDryRunOutput(req *launchRequest) dryRunOutput {
+	platform := req.Platform
+	env := platformEnvMap(platform)
+	_, dropped := req.EnvPolicy.filter(os.Environ(), env)
+	for key, value := range env {
+		if isSecretEnvKey(key) {
+			env[key] = maskToken(value)
+		}
+	}
+	env[platform.tokenEnvKey()] = maskToken(platform.AnthropicAuthToken)
+	// dry-run 不运行 token_command，只提示令牌的来源
+	if platform.TokenCommand != "" {
+		env[platform.tokenEnvKey()] = "$(" + platform.TokenCommand + ")"
+	}
+
+	out := dryRunOutput{
+		Platform:   platform.Name,
+		Vendor:     platform.Vendor,
+		Source:     string(req.Source),
+		Detail:     req.Detail,
+		Env:        env,
+		Command:    append([]string{"claude"}, req.Args...),
+		Supervise:  req.Supervise,
+		Record:     req.Record,
+		Hooks:      req.Hooks,
+		EnvPolicy:  req.EnvPolicy.Mode,
+		DroppedEnv: dropped,
+		Fallback:   platform.Fallback,
+		Rule:       req.Rule,
+	}
+	if req.Limits.MaxSession > 0 {
+		out.MaxSession = req.Limits.MaxSession.String()
+	}
+	if req.Limits.IdleTimeout > 0 {
+		out.IdleTimeout = req.Limits.IdleTimeout.String()
+	}
+	return out
+}
+
+// sortedEnvKeys 返回 dry-run 环境变量的输出顺序：ANTHROPIC_* 在前，其余按字母序
+func (d dryRunOutput) sortedEnvKeys() []string {
+	keys := sortedKeys(d.Env)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return strings.HasPrefix(keys[i], "ANTHROPIC_") && !strings.HasPrefix(keys[j], "ANTHROPIC_")
+	})
+	return keys
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestPlatformOutputMasksToken tests that structured platform output masks the token and secret env values unless revealed
+func TestPlatformOutputMasksToken(t *testing.T) {
+	config := &Config{Default: "prod"}
+	platform := &Platform{Name: "prod", AnthropicBaseURL: "https://a", AnthropicAuthToken: "sk-1234567890abcdef", AnthropicModel: "m",
+		Env: map[string]string{"API_TIMEOUT_MS": "600000", "AWS_SECRET_ACCESS_KEY": "aws-secret-value"}}
+
+	out := newPlatformOutput(platform, config, false)
+	if out.Token != maskToken(platform.AnthropicAuthToken) {
+		t.Errorf("Expected masked token, got %s", out.Token)
+	}
+	if !out.Default || out.AuthMode != authModeToken {
+		t.Errorf("Expected default platform with auth_token mode, got %+v", out)
+	}
+	if want := map[string]string{"API_TIMEOUT_MS": "600000", "AWS_SECRET_ACCESS_KEY": maskToken("aws-secret-value")}; !reflect.DeepEqual(out.Env, want) {
+		t.Errorf("Expected secret-named env value to be masked, got %v", out.Env)
+	}
+	if platform.Env["AWS_SECRET_ACCESS_KEY"] != "aws-secret-value" {
+		t.Error("Expected masking not to modify the platform env")
+	}
+	revealed := newPlatformOutput(platform, config, true)
+	if revealed.Token != platform.AnthropicAuthToken || revealed.Env["AWS_SECRET_ACCESS_KEY"] != "aws-secret-value" {
+		t.Errorf("Expected full token and env with reveal, got %+v", revealed)
+	}
+}
+
+// mustLaunchRequest 构建启动请求，失败时终止测试
+func mustLaunchRequest(t *testing.T, config *Config, res *Resolution, claudeArgs []string) *launchRequest {
+	t.Helper()
+	req, err := newLaunchRequest(config, res, claudeArgs)
+	if err != nil {
+		t.Fatalf("Expected no error building launch request, got %v", err)
+	}
+	return req
+}
+
+// TestDryRunOutput tests the structured dry-run output
+func TestDryRunOutput(t *testing.T) {
+	platform := &Platform{Name: "prod", AnthropicBaseURL: "https://a", AnthropicAuthToken: "sk-1234567890abcdef", AnthropicModel: "m",
+		Env: map[string]string{"API_TIMEOUT_MS": "1", "GITHUB_TOKEN": "ghp-1234567890"}}
+	res := &Resolution{Platform: platform, Source: SourceFlag, Detail: "命令行参数"}
+
+	config := &Config{Hooks: &Hooks{PreLaunch: []string{"vpn-up"}}}
+	platform.Hooks = &Hooks{PostLaunch: []string{"notify"}, Timeout: "5s"}
+
+	out := newDryRunOutput(mustLaunchRequest(t, config, res, []string{"--continue"}))
+	if out.Env["ANTHROPIC_AUTH_TOKEN"] != maskToken(platform.AnthropicAuthToken) {
+		t.Errorf("Expected masked token in env, got %s", out.Env["ANTHROPIC_AUTH_TOKEN"])
+	}
+	if out.Env["GITHUB_TOKEN"] != maskToken("ghp-1234567890") || out.Env["API_TIMEOUT_MS"] != "1" {
+		t.Errorf("Expected secret-named platform env to be masked, got %v", out.Env)
+	}
+	if !reflect.DeepEqual(out.Command, []string{"claude", "--continue"}) {
+		t.Errorf("Expected claude command, got %v", out.Command)
+	}
+	if !reflect.DeepEqual(out.Hooks.PreLaunch, []string{"vpn-up"}) || !reflect.DeepEqual(out.Hooks.PostLaunch, []string{"notify"}) {
+		t.Errorf("Expected global and platform hooks in dry-run, got %+v", out.Hooks)
+	}
+	if !out.Supervise {
+		t.Error("Expected post-launch hooks to enable supervised mode")
+	}
+	want := []string{"ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_BASE_URL", "ANTHROPIC_MODEL", "API_TIMEOUT_MS", "GITHUB_TOKEN"}
+	if got := out.sortedEnvKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected env order %v, got %v", want, got)
+	}
+}
+
+// TestStructuredOutputErrors tests that errors never reach stdout with --output json|yaml
+func TestStructuredOutputErrors(t *testing.T) {
+	cfg := writeExecConfig(t)
+	broken := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(broken, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"show", "zz", "-f", cfg, "--output", "json"},
+		{"list", "-f", broken, "--output", "yaml"},
+		{"-f", cfg, "-p", "zz", "--dry-run", "--output", "json"},
+	} {
+		stdout, stderr, code := runCCGate(t, args...)
+		if code != 1 || stdout != "" || !strings.Contains(stderr, "错误") {
+			t.Errorf("%q: expected error on stderr only, got code %d, stdout %q, stderr %q", args, code, stdout, stderr)
+		}
+	}
+}
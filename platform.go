@@ -134,9 +134,20 @@ func addPlatform() (Platform, error) {
 		break
 	}
 
+	// 尝试获取模型列表，用于下面的模型选择器
+	Spacer(theme.Spacing.XS, theme)
+	models := discoverModels(&platform)
+
 	// 模型
 	pterm.Printf("\n%s\n", theme.Colors.Primary.Sprint("🤖 ANTHROPIC_MODEL"))
-	for {
+	if len(models) > 0 {
+		model, _, err := pickModel(models, "选择模型 (↑↓ 导航, 直接输入搜索, Enter 确认)", false)
+		if err != nil {
+			return platform, fmt.Errorf("获取模型失败: %w", err)
+		}
+		platform.AnthropicModel = model
+	}
+	for platform.AnthropicModel == "" {
 		model, err := pterm.DefaultInteractiveTextInput.
 			WithDefaultText("请输入模型名称（如：claude-sonnet-4-20250514）").
 			Show()
@@ -152,18 +163,28 @@ func addPlatform() (Platform, error) {
 		}
 
 		platform.AnthropicModel = strings.TrimSpace(model)
-		break
 	}
 
 	// 快速模型（可选）
 	pterm.Printf("\n%s\n", theme.Colors.Secondary.Sprint("⚡ ANTHROPIC_SMALL_FAST_MODEL（可选）"))
-	fastModel, err := pterm.DefaultInteractiveTextInput.
-		WithDefaultText("请输入快速模型名称（如：claude-3-5-haiku-20241022，回车跳过）").
-		Show()
-	if err != nil {
-		return platform, fmt.Errorf("获取快速模型失败: %w", err)
+	decided := false
+	if len(models) > 0 {
+		fastModel, ok, err := pickModel(models, "选择快速模型 (↑↓ 导航, 直接输入搜索, Enter 确认)", true)
+		if err != nil {
+			return platform, fmt.Errorf("获取快速模型失败: %w", err)
+		}
+		platform.AnthropicSmallModel = fastModel
+		decided = ok
+	}
+	if !decided {
+		fastModel, err := pterm.DefaultInteractiveTextInput.
+			WithDefaultText("请输入快速模型名称（如：claude-3-5-haiku-20241022，回车跳过）").
+			Show()
+		if err != nil {
+			return platform, fmt.Errorf("获取快速模型失败: %w", err)
+		}
+		platform.AnthropicSmallModel = strings.TrimSpace(fastModel)
 	}
-	platform.AnthropicSmallModel = strings.TrimSpace(fastModel)
 
 	Spacer(theme.Spacing.MD, theme)
 
@@ -186,6 +207,47 @@ func deletePlatform(platforms []Platform, name string) ([]Platform, error) {
 	return nil, fmt.Errorf("平台 '%s' 不存在", name)
 }
 
+// removePlatformReferences 移除配置中指向已删除平台的默认设置、目录绑定、规则和备用平台
+// 返回被移除的引用说明，用于在删除结果中展示
+func removePlatformReferences(config *Config, name string) []string {
+	var removed []string
+	if config.Default == name {
+		config.Default = ""
+		removed = append(removed, "默认平台")
+	}
+	for _, dir := range sortedKeys(config.Directories) {
+		if config.Directories[dir] == name {
+			delete(config.Directories, dir)
+			removed = append(removed, "目录绑定 "+dir)
+		}
+	}
+	rules := config.Rules[:0]
+	for i, rule := range config.Rules {
+		if rule.Platform == name {
+			removed = append(removed, fmt.Sprintf("规则 #%d（%s）", i+1, rule.String()))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	config.Rules = rules
+	for i := range config.Platforms {
+		p := &config.Platforms[i]
+		fallback := p.Fallback[:0]
+		for _, f := range p.Fallback {
+			if f == name {
+				removed = append(removed, fmt.Sprintf("平台 %s 的备用平台", p.Name))
+				continue
+			}
+			fallback = append(fallback, f)
+		}
+		if len(fallback) == 0 {
+			fallback = nil
+		}
+		p.Fallback = fallback
+	}
+	return removed
+}
+
 // updateOrAddPlatform 更新或添加平台
 func updateOrAddPlatform(platforms []Platform, newPlatform Platform) []Platform {
 	for i, p := range platforms {
@@ -0,0 +1,320 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// builtinPresets 内置的厂商预设
+//
+//go:embed presets.json
+var builtinPresets []byte
+
+// Preset 厂商预设：添加平台时只需填写名称和令牌
+type Preset struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Vendor  string `json:"vendor"`
+	BaseURL string `json:"base_url"` // 为空时在添加平台时询问（如内部网关）
+	// Models 推荐模型，第一个作为 ANTHROPIC_MODEL
+	Models     []string          `json:"models"`
+	SmallModel string            `json:"small_model,omitempty"`
+	AuthMode   string            `json:"auth_mode,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	// TokenURL 获取令牌的页面，添加平台时作为提示
+	TokenURL string `json:"token_url,omitempty"`
+}
+
+var addPreset string
+
+// init 子命令
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "首次使用向导：选择厂商预设并添加平台",
+	Long: `选择一个厂商预设，只需填写平台名称和令牌即可完成配置。
+没有任何平台时，添加的平台会被设为默认平台。
+
+预设包含 Base URL、推荐模型、令牌传递方式和厂商要求的额外环境变量。
+可以在 ~/.ccgate/presets.json 中添加或覆盖预设（JSON 数组，相同 id 覆盖内置预设）。
+
+示例:
+  ccgate init
+  ccgate add --preset kimi`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return NewUserError("init 需要在交互式终端中运行", "或直接编辑配置文件 ~/.ccgate/config.json")
+		}
+
+		presets, err := loadPresets()
+		if err != nil {
+			return err
+		}
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		theme := DefaultTheme()
+		pterm.Info.Printf("%s\n", theme.Colors.Primary.Sprint("👋 欢迎使用 ccgate"))
+		if len(config.Platforms) > 0 {
+			DisplayInfo(fmt.Sprintf("已配置 %d 个平台，将继续添加新平台", len(config.Platforms)), theme)
+		}
+		Spacer(theme.Spacing.XS, theme)
+
+		preset, err := pickPreset(presets)
+		if err != nil {
+			return err
+		}
+
+		var platform Platform
+		if preset == nil {
+			platform, err = addPlatform()
+		} else {
+			platform, err = promptPresetPlatform(preset, config)
+		}
+		if err != nil {
+			return err
+		}
+		return savePresetPlatform(config, platform)
+	},
+}
+
+func init() {
+	addCmd.Flags().StringVar(&addPreset, "preset", "", "使用厂商预设（只需填写名称和令牌）")
+}
+
+// presetsPath 返回本地预设文件路径
+func presetsPath() string {
+	return filepath.Join(filepath.Dir(getConfigPath()), "presets.json")
+}
+
+// loadPresets 加载内置预设，并合并本地预设文件
+func loadPresets() ([]Preset, error) {
+	presets, err := parsePresets(builtinPresets)
+	if err != nil {
+		return nil, fmt.Errorf("内置预设无效: %w", err)
+	}
+
+	path := presetsPath()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取预设文件 %s 失败: %w", path, err)
+	}
+	local, err := parsePresets(data)
+	if err != nil {
+		return nil, NewConfigError(
+			fmt.Sprintf("预设文件 %s 无效: %v", path, err),
+			"预设文件应为 JSON 数组，每个预设至少包含 id 和 models")
+	}
+	return mergePresets(presets, local), nil
+}
+
+// parsePresets 解析并校验预设列表
+func parsePresets(data []byte) ([]Preset, error) {
+	var presets []Preset
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, err
+	}
+	for i, p := range presets {
+		if p.ID == "" {
+			return nil, fmt.Errorf("第 %d 个预设缺少 id", i+1)
+		}
+		if len(p.Models) == 0 {
+			return nil, fmt.Errorf("预设 %s 缺少 models", p.ID)
+		}
+		if p.AuthMode != "" && p.AuthMode != authModeToken && p.AuthMode != authModeAPIKey {
+			return nil, fmt.Errorf("预设 %s 的 auth_mode 无效: %s", p.ID, p.AuthMode)
+		}
+	}
+	return presets, nil
+}
+
+// mergePresets 合并预设，extra 中相同 id 的预设覆盖 base，其余追加到末尾
+func mergePresets(base, extra []Preset) []Preset {
+	merged := append([]Preset(nil), base...)
+	for _, p := range extra {
+		replaced := false
+		for i := range merged {
+			if merged[i].ID == p.ID {
+				merged[i] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// findPreset 按 id 查找预设
+func findPreset(presets []Preset, id string) (*Preset, error) {
+	for i := range presets {
+		if presets[i].ID == id {
+			return &presets[i], nil
+		}
+	}
+	ids := make([]string, len(presets))
+	for i, p := range presets {
+		ids[i] = p.ID
+	}
+	return nil, NewUserError(
+		fmt.Sprintf("预设 '%s' 不存在", id),
+		fmt.Sprintf("可用的预设: %s", strings.Join(ids, ", ")))
+}
+
+// platform 使用预设创建平台配置
+func (p *Preset) platform(name, token string) Platform {
+	platform := Platform{
+		Name:                name,
+		Vendor:              p.Vendor,
+		AnthropicBaseURL:    p.BaseURL,
+		AnthropicAuthToken:  token,
+		AnthropicModel:      p.Models[0],
+		AnthropicSmallModel: p.SmallModel,
+		AuthMode:            p.AuthMode,
+	}
+	if len(p.Env) > 0 {
+		platform.Env = make(map[string]string, len(p.Env))
+		for key, value := range p.Env {
+			platform.Env[key] = value
+		}
+	}
+	return platform
+}
+
+// 预设选择器中的自定义选项
+const presetOptionCustom = "✏️  自定义（手动填写全部配置）"
+
+// pickPreset 交互式选择预设，选择自定义时返回 nil
+func pickPreset(presets []Preset) (*Preset, error) {
+	options := make([]string, 0, len(presets)+1)
+	for _, p := range presets {
+		options = append(options, fmt.Sprintf("%-12s %s", p.ID, p.Name))
+	}
+	options = append(options, presetOptionCustom)
+
+	selected, err := pterm.DefaultInteractiveSelect.
+		WithOptions(options).
+		WithDefaultText("选择厂商预设 (↑↓ 导航, Enter 确认)").
+		WithMaxHeight(15).
+		Show()
+	if err != nil {
+		return nil, fmt.Errorf("选择预设失败: %w", err)
+	}
+	if selected == presetOptionCustom {
+		return nil, nil
+	}
+	for i, option := range options {
+		if option == selected {
+			return &presets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("未知的预设: %s", selected)
+}
+
+// promptPresetPlatform 使用预设添加平台，只询问名称和令牌（预设没有 Base URL 时一并询问）
+func promptPresetPlatform(preset *Preset, config *Config) (Platform, error) {
+	theme := DefaultTheme()
+
+	pterm.Info.Printf("%s\n", theme.Colors.Primary.Sprintf("🚀 使用预设 %s 添加平台", preset.Name))
+	Spacer(theme.Spacing.XS, theme)
+
+	// 平台名称，默认使用预设 id
+	var name string
+	for name == "" {
+		input, err := pterm.DefaultInteractiveTextInput.
+			WithDefaultText("平台名称").
+			WithDefaultValue(preset.ID).
+			Show()
+		if err != nil {
+			return Platform{}, fmt.Errorf("获取平台名称失败: %w", err)
+		}
+		name = strings.TrimSpace(input)
+		if name == "" {
+			NewValidationError("平台名称不能为空", "请输入一个有效的平台名称").DisplayError(theme)
+		}
+	}
+	if _, err := findPlatformByName(config.Platforms, name); err == nil {
+		DisplayWarning(fmt.Sprintf("平台 '%s' 已存在，将更新其配置", name), theme)
+	}
+
+	// 预设没有 Base URL 时（如内部网关）需要填写
+	baseURL := preset.BaseURL
+	for baseURL == "" {
+		input, err := pterm.DefaultInteractiveTextInput.
+			WithDefaultText("请输入 API Base URL（如：https://gateway.example.com）").
+			Show()
+		if err != nil {
+			return Platform{}, fmt.Errorf("获取 API URL 失败: %w", err)
+		}
+		baseURL = strings.TrimSpace(input)
+		if baseURL == "" {
+			NewValidationError("API URL 不能为空", "请输入有效的 API URL").DisplayError(theme)
+		}
+	}
+
+	// 令牌
+	if preset.TokenURL != "" {
+		DisplayInfo(fmt.Sprintf("令牌可在 %s 获取", preset.TokenURL), theme)
+	}
+	var token string
+	for token == "" {
+		input, err := pterm.DefaultInteractiveTextInput.
+			WithDefaultText("请输入认证令牌（API Key）").
+			WithMask("*").
+			Show()
+		if err != nil {
+			return Platform{}, fmt.Errorf("获取认证令牌失败: %w", err)
+		}
+		token = strings.TrimSpace(input)
+		if token == "" {
+			NewValidationError("认证令牌不能为空", "请输入有效的认证令牌").DisplayError(theme)
+		}
+	}
+
+	platform := preset.platform(name, token)
+	platform.AnthropicBaseURL = baseURL
+	if err := platform.Validate(); err != nil {
+		return platform, fmt.Errorf("平台配置验证失败: %w", err)
+	}
+	return platform, nil
+}
+
+// savePresetPlatform 保存通过预设或向导添加的平台，第一个平台自动设为默认平台
+func savePresetPlatform(config *Config, platform Platform) error {
+	theme := DefaultTheme()
+
+	_, existsErr := findPlatformByName(config.Platforms, platform.Name)
+	config.Platforms = updateOrAddPlatform(config.Platforms, platform)
+	if len(config.Platforms) == 1 && config.Default == "" {
+		config.Default = platform.Name
+	}
+	if err := saveConfig(config, cfgFile); err != nil {
+		return err
+	}
+
+	if existsErr == nil {
+		DisplayWarning(fmt.Sprintf("平台 '%s' 已存在，已更新配置", platform.Name), theme)
+	} else {
+		DisplaySuccess(fmt.Sprintf("平台 '%s' 添加成功", platform.Name), theme)
+	}
+	if config.Default == platform.Name {
+		DisplayInfo(fmt.Sprintf("'%s' 是默认平台，直接运行 ccgate 即可启动 claude", platform.Name), theme)
+	}
+	DisplayInfo(fmt.Sprintf("运行 'ccgate test %s' 验证连通性和令牌", platform.Name), theme)
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+// TestBuiltinPresets tests that the embedded preset catalog is valid
+func TestBuiltinPresets(t *testing.T) {
+	presets, err := parsePresets(builtinPresets)
+	if err != nil {
+		t.Fatalf("Expected valid builtin presets, got %v", err)
+	}
+	for _, id := range []string{"anthropic", "kimi", "deepseek", "glm", "openrouter", "gateway"} {
+		if _, err := findPreset(presets, id); err != nil {
+			t.Errorf("Expected builtin preset %s, got %v", id, err)
+		}
+	}
+	if _, err := findPreset(presets, "nope"); err == nil {
+		t.Error("Expected error for unknown preset, got nil")
+	}
+}
+
+// TestMergePresets tests that local presets override and extend the builtin ones
+func TestMergePresets(t *testing.T) {
+	base := []Preset{{ID: "kimi", Models: []string{"a"}}, {ID: "glm", Models: []string{"b"}}}
+	local := []Preset{{ID: "kimi", Models: []string{"c"}}, {ID: "corp", Models: []string{"d"}}}
+
+	merged := mergePresets(base, local)
+	if len(merged) != 3 {
+		t.Fatalf("Expected 3 presets, got %d", len(merged))
+	}
+	if merged[0].Models[0] != "c" {
+		t.Errorf("Expected local kimi preset to override builtin, got %v", merged[0].Models)
+	}
+	if merged[2].ID != "corp" {
+		t.Errorf("Expected new preset appended, got %s", merged[2].ID)
+	}
+
+	if _, err := parsePresets([]byte(`[{"id": "x"}]`)); err == nil {
+		t.Error("Expected error for preset without models, got nil")
+	}
+}
+
+// TestPresetPlatform tests the platform created from a preset and its environment
+func TestPresetPlatform(t *testing.T) {
+	preset := &Preset{
+		ID:         "deepseek",
+		Vendor:     "DeepSeek",
+		BaseURL:    "https://api.deepseek.com/anthropic",
+		Models:     []string{"deepseek-chat"},
+		SmallModel: "deepseek-chat",
+		AuthMode:   authModeAPIKey,
+		Env:        map[string]string{"API_TIMEOUT_MS": "600000"},
+	}
+
+	platform := preset.platform("ds", "sk-test")
+	if err := platform.Validate(); err != nil {
+		t.Fatalf("Expected valid platform, got %v", err)
+	}
+
+	env := platformEnvMap(&platform)
+	if env["ANTHROPIC_API_KEY"] != "sk-test" {
+		t.Errorf("Expected token in ANTHROPIC_API_KEY, got %q", env["ANTHROPIC_API_KEY"])
+	}
+	if _, ok := env["ANTHROPIC_AUTH_TOKEN"]; ok {
+		t.Error("Expected ANTHROPIC_AUTH_TOKEN to be unset in api_key mode")
+	}
+	if env["API_TIMEOUT_MS"] != "600000" {
+		t.Errorf("Expected extra env from preset, got %q", env["API_TIMEOUT_MS"])
+	}
+
+	// 修改平台的 Env 不应影响预设
+	platform.Env["API_TIMEOUT_MS"] = "1"
+	if preset.Env["API_TIMEOUT_MS"] != "600000" {
+		t.Error("Expected preset env to be copied, not shared")
+	}
+}
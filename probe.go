@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// anthropicVersion 请求 Messages API 时使用的版本头
+const anthropicVersion = "2023-06-01"
+
+var (
+	testAll     bool
+	testTimeout time.Duration
+)
+
+// test 子命令
+var testCmd = &cobra.Command{
+	Use:   "test [name]",
+	Short: "测试平台的连通性和认证",
+	Long: `向平台的 ANTHROPIC_BASE_URL 发送一个最小的 Messages API 请求，
+报告延迟、HTTP 状态和返回的模型，并对常见失败给出修复建议。
+该请求（max_tokens=1）会按平台的计费规则产生少量费用。
+
+示例:
+  ccgate test prod             # 测试指定平台
+  ccgate test --all            # 并发测试所有平台
+  ccgate test --all --timeout 5s`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		var platforms []Platform
+		switch {
+		case testAll:
+			if len(args) > 0 {
+				return fmt.Errorf("--all 不能与平台名称同时使用")
+			}
+			if len(config.Platforms) == 0 {
+				DisplayWarning("没有配置任何平台", DefaultTheme())
+				return nil
+			}
+			platforms = config.Platforms
+		case len(args) == 1:
+			platform, err := findPlatformByName(config.Platforms, args[0])
+			if err != nil {
+				return unknownPlatformError(config.Platforms, args[0], err)
+			}
+			platforms = []Platform{*platform}
+		default:
+			res, err := selectPlatform(config, "", nil, true)
+			if err != nil {
+				return err
+			}
+			platforms = []Platform{*res.Platform}
+		}
+
+		results := probePlatforms(context.Background(), &http.Client{}, platforms, testTimeout)
+		printProbeResults(results)
+
+		for _, r := range results {
+			if r.Err != nil {
+				os.Exit(1)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	testCmd.Flags().BoolVar(&testAll, "all", false, "并发测试所有平台")
+	testCmd.Flags().DurationVar(&testTimeout, "timeout", 10*time.Second, "单个平台的超时时间")
+}
+
+// ProbeResult 表示一次连通性测试的结果
+type ProbeResult struct {
+	Platform   string
+	URL        string
+	StatusCode int
+	Latency    time.Duration
+	Model      string
+	Err        *UIError
+}
+
+// probePlatforms 并发测试多个平台，结果顺序与输入一致
+func probePlatforms(ctx context.Context, client *http.Client, platforms []Platform, timeout time.Duration) []ProbeResult {
+	results := make([]ProbeResult, len(platforms))
+
+	var wg sync.WaitGroup
+	for i := range platforms {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i] = probePlatform(pctx, client, &platforms[i])
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// probePlatform 向平台发送最小的 Messages API 请求
+func probePlatform(ctx context.Context, client *http.Client, platform *Platform) ProbeResult {
+	result := ProbeResult{
+		Platform: platform.Name,
+		URL:      messagesURL(platform.AnthropicBaseURL),
+	}
+	if uiErr := ensurePlatformToken(platform); uiErr != nil {
+		result.Err = uiErr
+		return result
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"model":      platform.AnthropicModel,
+		"max_tokens": 1,
+		"messages": []map[string]string{
+			{"role": "user", "content": "ping"},
+		},
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, result.URL, bytes.NewReader(body))
+	if err != nil {
+		result.Err = NewConfigError(
+			fmt.Sprintf("ANTHROPIC_BASE_URL 无效: %v", err),
+			"检查 ANTHROPIC_BASE_URL 格式，例如 https://api.anthropic.com")
+		return result
+	}
+	setAnthropicHeaders(req, platform.AnthropicAuthToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = classifyTransportError(err, platform.AnthropicBaseURL)
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		var msg struct {
+			Model string `json:"model"`
+		}
+		_ = json.Unmarshal(data, &msg)
+		result.Model = msg.Model
+		return result
+	}
+
+	result.Err = classifyHTTPStatus(resp.StatusCode, apiErrorMessage(data), platform)
+	return result
+}
+
+// messagesURL 根据 ANTHROPIC_BASE_URL 生成 Messages API 地址（与 claude 的拼接方式一致）
+func messagesURL(baseURL string) string {
+	return strings.TrimRight(baseURL, "/") + "/v1/messages"
+}
+
+// setAnthropicHeaders 设置认证及版本请求头，同时兼容 x-api-key 和 Bearer 两种方式
+func setAnthropicHeaders(req *http.Request, token string) {
+	req.Header.Set("x-api-key", token)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("anthropic-version", anthropicVersion)
+}
+
+// apiErrorMessage 从 Anthropic 或 OpenAI 风格的错误响应中提取错误信息
+func apiErrorMessage(data []byte) string {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil {
+		if body.Error.Message != "" {
+			return body.Error.Message
+		}
+		if body.Message != "" {
+			return body.Message
+		}
+	}
+
+	text := strings.TrimSpace(string(data))
+	if len(text) > 200 {
+		text = text[:200] + "..."
+	}
+	return text
+}
+
+// classifyTransportError 将请求阶段的错误归类为带修复建议的 UIError
+func classifyTransportError(err error, baseURL string) *UIError {
+	host := baseURL
+	if u, perr := url.Parse(baseURL); perr == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	var dnsErr *net.DNSError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalid x509.CertificateInvalidError
+	var verifyErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return NewNetworkError(
+			fmt.Sprintf("无法解析域名 %s", dnsErr.Name),
+			"检查 ANTHROPIC_BASE_URL 中的域名拼写，以及 DNS、VPN 或代理设置")
+	case errors.As(err, &verifyErr), errors.As(err, &unknownAuthority),
+		errors.As(err, &hostnameErr), errors.As(err, &certInvalid):
+		return NewNetworkError(
+			fmt.Sprintf("TLS 证书校验失败: %v", err),
+			fmt.Sprintf("确认 %s 的证书有效；使用企业代理时请安装其根证书", host))
+	case errors.As(err, &recordErr), strings.Contains(err.Error(), "HTTP response to HTTPS client"):
+		return NewNetworkError(
+			"TLS 握手失败，服务端可能不支持 HTTPS",
+			"检查 ANTHROPIC_BASE_URL 应使用 http:// 还是 https://")
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return NewNetworkError(
+			fmt.Sprintf("连接 %s 超时", host),
+			"检查网络连接和代理设置，或使用 --timeout 增加超时时间")
+	default:
+		return NewNetworkError(
+			fmt.Sprintf("无法连接 %s: %v", host, err),
+			"检查服务地址和端口是否正确、服务是否在运行，以及网络和代理设置")
+	}
+}
+
+// classifyHTTPStatus 将非 2xx 响应归类为带修复建议的 UIError
+func classifyHTTPStatus(status int, message string, platform *Platform) *UIError {
+	detail := fmt.Sprintf("HTTP %d", status)
+	if message != "" {
+		detail = fmt.Sprintf("HTTP %d: %s", status, message)
+	}
+
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return NewConfigError(
+			"认证失败（"+detail+"）",
+			fmt.Sprintf("检查 ANTHROPIC_AUTH_TOKEN 是否正确或已过期，可运行 'ccgate add' 并使用名称 '%s' 更新", platform.Name))
+	case status == http.StatusNotFound:
+		recovery := "确认 ANTHROPIC_BASE_URL 是 Anthropic 兼容接口的根地址，claude 会自动拼接 /v1/messages"
+		if strings.HasSuffix(strings.TrimRight(platform.AnthropicBaseURL, "/"), "/v1") {
+			recovery = "ANTHROPIC_BASE_URL 不应以 /v1 结尾，请去掉该后缀"
+		}
+		return NewConfigError("接口路径不存在（"+detail+"）", recovery)
+	case status == http.StatusTooManyRequests:
+		err := NewNetworkError(
+			"请求被限流或额度不足（"+detail+"）",
+			"稍后重试，或检查该平台账户的额度和速率限制")
+		err.Severity = SeverityWarning
+		return err
+	case status >= 500:
+		return NewNetworkError(
+			"服务端错误（"+detail+"）",
+			"平台服务暂时不可用，请稍后重试或联系平台方")
+	default:
+		return NewConfigError(
+			"请求被拒绝（"+detail+"）",
+			fmt.Sprintf("检查 ANTHROPIC_MODEL（%s）是否为该平台支持的模型名称", platform.AnthropicModel))
+	}
+}
+
+// printProbeResults 输出连通性测试结果
+func printProbeResults(results []ProbeResult) {
+	theme := DefaultTheme()
+
+	tableData := pterm.TableData{{"平台", "状态", "延迟", "HTTP", "模型"}}
+	for _, r := range results {
+		status := theme.Colors.Success.Sprint("✓ 正常")
+		if r.Err != nil {
+			status = theme.Colors.Error.Sprint("✗ 失败")
+		}
+		httpStatus := "-"
+		if r.StatusCode != 0 {
+			httpStatus = fmt.Sprintf("%d", r.StatusCode)
+		}
+		model := r.Model
+		if model == "" {
+			model = "-"
+		}
+		tableData = append(tableData, []string{
+			theme.Colors.Primary.Sprint(r.Platform),
+			status,
+			r.Latency.Round(time.Millisecond).String(),
+			httpStatus,
+			model,
+		})
+	}
+
+	pterm.DefaultTable.WithHasHeader(true).
+		WithBoxed(true).
+		WithData(tableData).
+		Render()
+	Spacer(theme.Spacing.XS, theme)
+
+	for _, r := range results {
+		if r.Err == nil {
+			continue
+		}
+		pterm.Printf("%s %s\n", theme.Colors.Secondary.Sprintf("[%s]", r.Platform), r.URL)
+		r.Err.DisplayError(theme)
+	}
+}
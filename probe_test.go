@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestProbePlatform tests probing against an httptest stand-in for the Messages API
+func TestProbePlatform(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantModel string
+		wantType  ErrorType
+		wantErr   bool
+	}{
+		{name: "ok", status: http.StatusOK, body: `{"model":"echo-model"}`, wantModel: "echo-model"},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{"error":{"message":"invalid x-api-key"}}`, wantType: ErrorTypeConfig, wantErr: true},
+		{name: "not found", status: http.StatusNotFound, wantType: ErrorTypeConfig, wantErr: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantType: ErrorTypeNetwork, wantErr: true},
+		{name: "server error", status: http.StatusBadGateway, wantType: ErrorTypeNetwork, wantErr: true},
+		{name: "bad model", status: http.StatusBadRequest, body: `{"error":{"message":"unknown model"}}`, wantType: ErrorTypeConfig, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/messages" {
+					t.Errorf("Expected path /v1/messages, got %s", r.URL.Path)
+				}
+				if r.Header.Get("x-api-key") != "test-token" {
+					t.Errorf("Expected x-api-key header, got %q", r.Header.Get("x-api-key"))
+				}
+				var req struct {
+					Model     string `json:"model"`
+					MaxTokens int    `json:"max_tokens"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "test-model" || req.MaxTokens != 1 {
+					t.Errorf("Unexpected request body: %+v, %v", req, err)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			platform := &Platform{
+				Name:               "test",
+				AnthropicBaseURL:   server.URL + "/",
+				AnthropicAuthToken: "test-token",
+				AnthropicModel:     "test-model",
+			}
+			result := probePlatform(context.Background(), server.Client(), platform)
+
+			if result.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, result.StatusCode)
+			}
+			if !tt.wantErr {
+				if result.Err != nil {
+					t.Fatalf("Expected no error, got %v", result.Err.Message)
+				}
+				if result.Model != tt.wantModel {
+					t.Errorf("Expected model %s, got %s", tt.wantModel, result.Model)
+				}
+				return
+			}
+			if result.Err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if result.Err.Type != tt.wantType {
+				t.Errorf("Expected error type %d, got %d", tt.wantType, result.Err.Type)
+			}
+			if result.Err.Recovery == "" {
+				t.Error("Expected recovery hint, got empty")
+			}
+		})
+	}
+}
+
+// TestProbePlatformTransportErrors tests classification of TLS and timeout failures
+func TestProbePlatformTransportErrors(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	platform := &Platform{Name: "tls", AnthropicBaseURL: tlsServer.URL, AnthropicAuthToken: "t", AnthropicModel: "m"}
+	result := probePlatform(context.Background(), &http.Client{}, platform)
+	if result.Err == nil || result.Err.Type != ErrorTypeNetwork || !strings.Contains(result.Err.Message, "TLS") {
+		t.Errorf("Expected TLS network error, got %+v", result.Err)
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	platforms := []Platform{{Name: "slow", AnthropicBaseURL: slow.URL, AnthropicAuthToken: "t", AnthropicModel: "m"}}
+	results := probePlatforms(context.Background(), slow.Client(), platforms, 50*time.Millisecond)
+	if results[0].Err == nil || !strings.Contains(results[0].Err.Message, "超时") {
+		t.Errorf("Expected timeout error, got %+v", results[0].Err)
+	}
+}
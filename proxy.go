@@ -4,17 +4,66 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"syscall"
 
 	"github.com/fatih/color"
 )
 
-// proxyToClaude 透明代理到 claude，设置环境变量并执行
-func proxyToClaude(platform *Platform, claudeArgs []string) error {
-	// 设置环境变量
-	setEnvironmentVariables(platform)
+// launchRequest 一次 claude 启动所需的信息
+type launchRequest struct {
+	Platform *Platform
+	// Source、Detail 平台的选择依据，写入启动记录
+	Source ResolutionSource
+	Detail string
+	// Rule 按规则选择平台时命中的规则
+	Rule *Rule
+	Args []string
+	// Supervise 以托管子进程方式启动，而不是进程替换
+	Supervise bool
+	Hooks     launchHooks
+	// EnvPolicy 传递给 claude 的环境变量策略
+	EnvPolicy envPolicy
+	// Record 在 pty 中运行并录制会话，Secrets 为录制中需要脱敏的值
+	Record  bool
+	Secrets []string
+	// Failover 启动前健康检查失败时的处理，写入启动记录
+	Failover *failoverRecord
+	// Limits 会话时长和空闲时间限制
+	Limits sessionLimits
+}
+
+// newLaunchRequest 根据配置和平台选择结果构建启动请求
+// 配置了启动后钩子、录制会话或会话限制时自动使用托管模式，否则 exec 之后无法再执行
+func newLaunchRequest(config *Config, res *Resolution, claudeArgs []string) (*launchRequest, error) {
+	policy, err := resolveEnvPolicy(config, res.Platform)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := resolveHooks(config, res.Platform)
+	if err != nil {
+		return nil, err
+	}
+	limits := res.Platform.sessionLimits()
+	return &launchRequest{
+		Platform:  res.Platform,
+		Source:    res.Source,
+		Detail:    res.Detail,
+		Rule:      res.Rule,
+		Args:      claudeArgs,
+		Supervise: supervise || recordSession || config.Supervise || len(hooks.PostLaunch) > 0 || limits.enabled(),
+		Record:    recordSession,
+		Secrets:   configSecrets(config),
+		Hooks:     hooks,
+		EnvPolicy: policy,
+		Limits:    limits,
+	}, nil
+}
 
+// proxyToClaude 透明代理到 claude，设置环境变量并执行
+// 默认使用 syscall.Exec 进程替换；托管模式下作为子进程运行并在退出后记录状态
+func proxyToClaude(req *launchRequest) error {
 	// 查找 claude 可执行文件
 	claudePath, err := exec.LookPath("claude")
 	if err != nil {
@@ -25,41 +74,144 @@ func proxyToClaude(platform *Platform, claudeArgs []string) error {
 		)
 	}
 
+	// 在执行启动前钩子之前检查，避免钩子执行后才发现无法启动
+	if req.Supervise {
+		if err := checkSupervised(req); err != nil {
+			return err
+		}
+	}
+
+	args := append([]string{"claude"}, req.Args...)
+	env, err := platformEnviron(req.Platform, req.EnvPolicy)
+	if err != nil {
+		return err
+	}
+
+	// 启动前钩子失败时中止启动
+	hookEnv := append(env, hookContextEnv(hookPreLaunch, req.Platform, req.Args)...)
+	if err := runHooks(hookPreLaunch, req.Hooks.PreLaunch, hookEnv, req.Hooks.Timeout); err != nil {
+		return err
+	}
+
 	// 打印执行信息
-	printExecutionInfo(platform, claudeArgs)
+	printExecutionInfo(req.Platform, req.Args)
+
+	entry := newHistoryEntry(req.Platform, req.Source, req.Args)
+	entry.Failover = req.Failover
+
+	if req.Supervise {
+		return runSupervised(req, claudePath, args, env, entry)
+	}
 
-	// 使用 syscall.Exec 进行进程替换（完全透明）
-	args := append([]string{"claude"}, claudeArgs...)
-	env := os.Environ()
+	// 进程替换后无法再写入，因此在 exec 之前记录启动
+	recordLaunch(entry)
 
-	// 进程替换 - ccgate 进程被 claude 替换
+	// 进程替换 - ccgate 进程被 claude 替换（完全透明）
 	return syscall.Exec(claudePath, args, env)
 }
 
-// setEnvironmentVariables 设置平台相关的环境变量
-func setEnvironmentVariables(platform *Platform) {
-	os.Setenv("ANTHROPIC_BASE_URL", platform.AnthropicBaseURL)
-	os.Setenv("ANTHROPIC_AUTH_TOKEN", platform.AnthropicAuthToken)
-	os.Setenv("ANTHROPIC_MODEL", platform.AnthropicModel)
+// platformEnviron 返回应用平台配置并按策略过滤后的环境变量列表
+// claude 代理与 exec 子命令共用，保证两者看到的环境一致
+// 配置了 token_command 时先获取令牌（优先使用未过期的缓存）
+func platformEnviron(platform *Platform, policy envPolicy) ([]string, error) {
+	if uiErr := ensurePlatformToken(platform); uiErr != nil {
+		return nil, uiErr
+	}
+	return composeEnviron(os.Environ(), platform, policy), nil
+}
+
+// composeEnviron 在 base 之上设置平台变量并按策略过滤，不修改当前进程的环境
+// fanout 并发运行多个平台时，每个子进程因此拥有各自独立的环境
+func composeEnviron(base []string, platform *Platform, policy envPolicy) []string {
+	vars := platformEnvMap(platform)
+	replaced := replacedEnvKeys(platform)
+	env := make([]string, 0, len(base)+len(vars))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if !replaced[key] {
+			env = append(env, kv)
+		}
+	}
+	for _, key := range sortedKeys(vars) {
+		env = append(env, key+"="+vars[key])
+	}
+	kept, _ := policy.filter(env, vars)
+	return kept
+}
+
+// platformEnvMap 返回平台需要设置的环境变量
+func platformEnvMap(platform *Platform) map[string]string {
+	env := make(map[string]string, len(platform.Env)+4)
+	for key, value := range platform.Env {
+		env[key] = value
+	}
+	env["ANTHROPIC_BASE_URL"] = platform.AnthropicBaseURL
+	env[platform.tokenEnvKey()] = platform.AnthropicAuthToken
+	env["ANTHROPIC_MODEL"] = platform.AnthropicModel
 	if platform.AnthropicSmallModel != "" {
-		os.Setenv("ANTHROPIC_SMALL_FAST_MODEL", platform.AnthropicSmallModel)
+		env["ANTHROPIC_SMALL_FAST_MODEL"] = platform.AnthropicSmallModel
+	}
+	return env
+}
+
+// replacedEnvKeys 返回启动时不会从 ccgate 继承的变量：平台设置的变量以及平台不使用的令牌变量
+// ccgate doctor 据此判断 shell 中的变量是否会影响 claude
+func replacedEnvKeys(platform *Platform) map[string]bool {
+	keys := map[string]bool{platform.staleTokenEnvKey(): true}
+	for key := range platformEnvMap(platform) {
+		keys[key] = true
 	}
+	return keys
 }
 
-// printDryRun 打印 dry-run 模式的输出
-func printDryRun(platform *Platform, claudeArgs []string) {
+// sortedKeys 返回排序后的 map 键，用于稳定输出
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printDryRun 打印 dry-run 模式的输出，--output json|yaml 时输出结构化数据
+func printDryRun(config *Config, res *Resolution, claudeArgs []string) error {
+	req, err := newLaunchRequest(config, res, claudeArgs)
+	if err != nil {
+		return err
+	}
+	out := newDryRunOutput(req)
+	if structuredOutput() {
+		return writeStructured(out)
+	}
+
 	color.Yellow("\n=== DRY RUN MODE ===")
-	color.Cyan("\n→ 将使用平台: %s", platform.Name)
-	if platform.Vendor != "" {
-		fmt.Printf("  厂商: %s\n", platform.Vendor)
+	color.Cyan("\n→ 将使用平台: %s", out.Platform)
+	if out.Vendor != "" {
+		fmt.Printf("  厂商: %s\n", out.Vendor)
+	}
+	fmt.Printf("  选择依据: %s\n", res.Summary())
+	if len(out.Fallback) > 0 {
+		fmt.Printf("  备用平台: %s（启动前健康检查失败时切换）\n", strings.Join(out.Fallback, ", "))
 	}
 
 	color.Magenta("\n→ 将设置以下环境变量:")
-	fmt.Printf("  ANTHROPIC_BASE_URL=%s\n", platform.AnthropicBaseURL)
-	fmt.Printf("  ANTHROPIC_AUTH_TOKEN=%s\n", maskToken(platform.AnthropicAuthToken))
-	fmt.Printf("  ANTHROPIC_MODEL=%s\n", platform.AnthropicModel)
-	if platform.AnthropicSmallModel != "" {
-		fmt.Printf("  ANTHROPIC_SMALL_FAST_MODEL=%s\n", platform.AnthropicSmallModel)
+	for _, key := range out.sortedEnvKeys() {
+		fmt.Printf("  %s=%s\n", key, out.Env[key])
+	}
+
+	if out.EnvPolicy == envPolicyAllowlist {
+		color.Magenta("\n→ 环境变量策略: allowlist，将丢弃 %d 个变量:", len(out.DroppedEnv))
+		for _, key := range out.DroppedEnv {
+			fmt.Printf("  %s\n", key)
+		}
+	}
+
+	if len(out.Hooks.PreLaunch) > 0 {
+		color.Blue("\n→ 启动前钩子:")
+		for _, h := range out.Hooks.PreLaunch {
+			fmt.Printf("  %s\n", h)
+		}
 	}
 
 	color.Green("\n→ 将执行命令:")
@@ -68,8 +220,24 @@ func printDryRun(platform *Platform, claudeArgs []string) {
 	} else {
 		fmt.Println("  claude (交互式)")
 	}
+	if out.Record {
+		fmt.Println("  （录制会话到 ~/.ccgate/sessions/）")
+	} else if out.Supervise {
+		fmt.Println("  （托管模式）")
+	}
+	if req.Limits.enabled() {
+		fmt.Printf("  （会话限制: %s）\n", req.Limits)
+	}
+
+	if len(out.Hooks.PostLaunch) > 0 {
+		color.Blue("\n→ 启动后钩子:")
+		for _, h := range out.Hooks.PostLaunch {
+			fmt.Printf("  %s\n", h)
+		}
+	}
 
 	color.Yellow("\n=== DRY RUN MODE ===\n")
+	return nil
 }
 
 // printExecutionInfo 打印即将执行的信息
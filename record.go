@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sessionExt 会话录制文件的扩展名（gzip 压缩的 asciicast v2）
+const sessionExt = ".cast.gz"
+
+// redactedMarker 录制中替换令牌的文本
+const redactedMarker = "[REDACTED]"
+
+// minSecretLength 短于该长度的值不做脱敏，避免误替换普通输出
+const minSecretLength = 8
+
+// sessionsDir 返回会话录制目录
+func sessionsDir() string {
+	return filepath.Join(filepath.Dir(getConfigPath()), "sessions")
+}
+
+// newSessionPath 返回新录制文件的路径，文件名即会话 ID，如 20250101-150405-prod
+func newSessionPath(platform string, now time.Time) string {
+	return filepath.Join(sessionsDir(), now.Format("20060102-150405")+"-"+sessionFileName(platform)+sessionExt)
+}
+
+// sessionFileName 将平台名称中路径分隔符等字符替换为 _，使录制文件始终位于录制目录内
+func sessionFileName(platform string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, platform)
+}
+
+// configSecrets 返回录制时需要脱敏的值：所有平台的令牌，以及 env 中名称包含 KEY、TOKEN、SECRET 的值
+func configSecrets(config *Config) []string {
+	var secrets []string
+	for _, p := range config.Platforms {
+		secrets = append(secrets, p.AnthropicAuthToken)
+		for key, value := range p.Env {
+			if isSecretEnvKey(key) {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+	return secrets
+}
+
+// castHeader asciicast v2 的头部
+type castHeader struct {
+	Version   int   `json:"version"`
+	Width     int   `json:"width"`
+	Height    int   `json:"height"`
+	Timestamp int64 `json:"timestamp"`
+	// Title 录制时使用的平台名称
+	Title string            `json:"title,omitempty"`
+	Env   map[string]string `json:"env,omitempty"`
+}
+
+// streamRedactor 在输出流中替换令牌
+// 令牌可能被拆分在两次读取之间，因此保留末尾不足一个令牌长度的数据，与下一次输出合并后再处理
+type streamRedactor struct {
+	secrets [][]byte
+	keep    int
+	pending []byte
+}
+
+// newStreamRedactor 创建脱敏器，忽略过短和重复的值
+func newStreamRedactor(secrets []string) *streamRedactor {
+	r := &streamRedactor{}
+	seen := make(map[string]bool)
+	for _, s := range secrets {
+		if len(s) < minSecretLength || seen[s] {
+			continue
+		}
+		seen[s] = true
+		r.secrets = append(r.secrets, []byte(s))
+		if len(s)-1 > r.keep {
+			r.keep = len(s) - 1
+		}
+	}
+	return r
+}
+
+// Write 处理一段输出，返回可以安全写入录制的部分
+func (r *streamRedactor) Write(p []byte) []byte {
+	buf := append(r.pending, p...)
+	for _, s := range r.secrets {
+		buf = bytes.ReplaceAll(buf, s, []byte(redactedMarker))
+	}
+
+	cut := len(buf) - r.keep
+	if cut < 0 {
+		cut = 0
+	}
+	// 不在多字节字符中间切分，asciicast 的输出必须是合法的 UTF-8
+	for cut > 0 && cut < len(buf) && !utf8.RuneStart(buf[cut]) {
+		cut--
+	}
+	r.pending = append([]byte(nil), buf[cut:]...)
+	return buf[:cut]
+}
+
+// Flush 返回剩余的全部输出
+func (r *streamRedactor) Flush() []byte {
+	out := r.pending
+	r.pending = nil
+	return out
+}
+
+// castWriter 写入 gzip 压缩的 asciicast v2 录制（权限 0600）
+type castWriter struct {
+	mu       sync.Mutex
+	title    string
+	file     *os.File
+	gz       *gzip.Writer
+	start    time.Time
+	redactor *streamRedactor
+}
+
+// newCastWriter 创建录制文件，title 为录制时使用的平台名称
+func newCastWriter(path, title string, secrets []string) (*castWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("创建录制目录失败: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("创建录制文件失败: %w", err)
+	}
+	return &castWriter{title: title, file: file, gz: gzip.NewWriter(file), redactor: newStreamRedactor(secrets)}, nil
+}
+
+// WriteHeader 写入头部并开始计时
+func (w *castWriter) WriteHeader(cols, rows int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.start = time.Now()
+	data, err := json.Marshal(castHeader{
+		Version:   2,
+		Width:     cols,
+		Height:    rows,
+		Timestamp: w.start.Unix(),
+		Title:     w.title,
+		Env:       map[string]string{"TERM": os.Getenv("TERM"), "SHELL": os.Getenv("SHELL")},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.gz.Write(append(data, '\n'))
+	return err
+}
+
+// Output 记录一段终端输出（脱敏后）
+func (w *castWriter) Output(p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.event("o", w.redactor.Write(p))
+}
+
+// Resize 记录终端尺寸变化
+func (w *castWriter) Resize(cols, rows int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.event("r", []byte(fmt.Sprintf("%dx%d", cols, rows)))
+}
+
+// event 写入一个事件，调用方需持有锁
+func (w *castWriter) event(kind string, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	elapsed := float64(time.Since(w.start).Microseconds()) / 1e6
+	line, err := json.Marshal([]any{elapsed, kind, string(data)})
+	if err != nil {
+		return err
+	}
+	_, err = w.gz.Write(append(line, '\n'))
+	return err
+}
+
+// Close 写出剩余的输出并关闭文件
+func (w *castWriter) Close() error {
+	w.mu.Lock()
+	err := w.event("o", w.redactor.Flush())
+	w.mu.Unlock()
+	return errors.Join(err, w.gz.Close(), w.file.Close())
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+// errRecordUnsupported 会话录制依赖 pty，仅支持类 Unix 系统
+func errRecordUnsupported() error {
+	return NewUserError(
+		"当前系统不支持 --record",
+		"会话录制需要 pty，仅在 Linux、macOS 等类 Unix 系统上可用；已有的录制仍可使用 'ccgate sessions' 查看")
+}
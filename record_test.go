@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStreamRedactor tests redaction of tokens split across reads
+func TestStreamRedactor(t *testing.T) {
+	secret := "sk-live-0123456789abcdef"
+	r := newStreamRedactor([]string{secret, "short", secret, ""})
+	if len(r.secrets) != 1 {
+		t.Fatalf("Expected short and duplicate secrets to be ignored, got %d", len(r.secrets))
+	}
+
+	var out []byte
+	for _, chunk := range []string{"token: sk-live-0123", "456789abcdef 完成", "，再见 " + secret} {
+		out = append(out, r.Write([]byte(chunk))...)
+	}
+	out = append(out, r.Flush()...)
+
+	want := "token: " + redactedMarker + " 完成，再见 " + redactedMarker
+	if string(out) != want {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+}
+
+// TestStreamRedactorUTF8 tests that held-back output never splits a multi-byte character
+func TestStreamRedactorUTF8(t *testing.T) {
+	r := newStreamRedactor([]string{"abcdefgh"})
+	for _, chunk := range []string{"你好世界", "再见"} {
+		if out := r.Write([]byte(chunk)); !isValidUTF8(out) {
+			t.Errorf("Expected valid UTF-8 prefix, got %q", out)
+		}
+	}
+}
+
+func isValidUTF8(b []byte) bool {
+	return strings.ToValidUTF8(string(b), "�") == string(b)
+}
+
+// TestSessionsListAndFind tests listing and looking up recordings by ID prefix
+func TestSessionsListAndFind(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, ts := range []string{"2025-01-01T10:00:00Z", "2025-01-02T10:00:00Z", "2025-01-02T11:00:00Z"} {
+		now, _ := time.Parse(time.RFC3339, ts)
+		w, err := newCastWriter(newSessionPath("prod", now), "prod", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = w.WriteHeader(80, 24)
+		_ = w.Output([]byte("hi"))
+		_ = w.Close()
+	}
+
+	sessions, err := listSessions()
+	if err != nil || len(sessions) != 3 {
+		t.Fatalf("Expected 3 sessions, got %d (%v)", len(sessions), err)
+	}
+	if sessions[0].ID != "20250102-110000-prod" || sessions[0].Platform != "prod" {
+		t.Errorf("Expected newest session first, got %+v", sessions[0])
+	}
+
+	if s, err := findSession(""); err != nil || s.ID != "20250102-110000-prod" {
+		t.Errorf("Expected latest session, got %v (%v)", s, err)
+	}
+	if s, err := findSession("20250101"); err != nil || s.ID != "20250101-100000-prod" {
+		t.Errorf("Expected unique prefix match, got %v (%v)", s, err)
+	}
+	if _, err := findSession("20250102"); err == nil {
+		t.Error("Expected error for ambiguous prefix, got nil")
+	}
+}
+
+// TestNewSessionPath tests that platform names cannot place recordings outside the sessions directory
+func TestNewSessionPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now, _ := time.Parse(time.RFC3339, "2025-01-01T10:00:00Z")
+
+	for name, want := range map[string]string{
+		"prod":        "20250101-100000-prod",
+		"../../etc/x": "20250101-100000-.._.._etc_x",
+		"..":          "20250101-100000-..",
+		`team\kimi 2`: "20250101-100000-team_kimi_2",
+		"智谱":          "20250101-100000-智谱",
+	} {
+		path := newSessionPath(name, now)
+		if filepath.Dir(path) != sessionsDir() || sessionID(path) != want {
+			t.Errorf("%q: expected %s inside %s, got %s", name, want, sessionsDir(), path)
+		}
+	}
+}
+
+// TestSessionsListMetadata tests that durations come from file metadata and completion uses file names only
+func TestSessionsListMetadata(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	now := time.Now()
+	path := newSessionPath("prod", now)
+	w, err := newCastWriter(path, "prod", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = w.WriteHeader(80, 24)
+	_ = w.Close()
+	header, err := readSessionHeader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := time.Unix(header.Timestamp, 0).Add(90 * time.Second)
+	if err := os.Chtimes(path, end, end); err != nil {
+		t.Fatal(err)
+	}
+	// 无法解析的文件不出现在列表中，但补全只看文件名
+	if err := os.WriteFile(filepath.Join(sessionsDir(), "20240101-000000-broken"+sessionExt), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := listSessions()
+	if err != nil || len(sessions) != 1 || sessions[0].DurationMS != 90000 {
+		t.Fatalf("Expected one session lasting 90s, got %+v (%v)", sessions, err)
+	}
+	ids, _ := completeSessionArg(nil, nil, "")
+	want := []string{sessionID(path), "20240101-000000-broken"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected completion %q, got %q", want, ids)
+	}
+}
+
+// TestPlaySessionTiming tests speed and idle compression during playback
+func TestPlaySessionTiming(t *testing.T) {
+	events := []castEvent{{Time: 1, Kind: "o", Data: "a"}, {Time: 1.5, Kind: "r", Data: "100x30"}, {Time: 11.5, Kind: "o", Data: "b"}}
+
+	var slept []time.Duration
+	var out strings.Builder
+	playSession(&out, events, 2, 2*time.Second, func(d time.Duration) { slept = append(slept, d) })
+
+	if out.String() != "ab" {
+		t.Errorf("Expected only output events, got %q", out.String())
+	}
+	want := []time.Duration{500 * time.Millisecond, 250 * time.Millisecond, time.Second}
+	if len(slept) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, slept)
+	}
+	for i := range want {
+		if slept[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, slept)
+		}
+	}
+}
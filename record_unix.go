@@ -0,0 +1,159 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"golang.org/x/term"
+)
+
+// recordedSignals 录制时转发给 claude 的信号
+// 终端处于原始模式，Ctrl+C 等按键经由 pty 直接发给 claude，这里转发的是通过 kill 发给 ccgate 的信号
+var recordedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2}
+
+// ptyProcess 在 pty 中运行命令并转发终端的输入输出
+// w 不为 nil 时输出同时写入录制文件；watchdog 不为 nil 时统计输入输出以判断空闲，并在到达限制时中断命令
+// Ctrl+Z 经由 pty 挂起命令，此时 ccgate 恢复终端并挂起自身，恢复（fg）后重新进入原始模式并继续命令
+func ptyProcess(path string, argv []string, env []string, w *castWriter, watchdog *sessionWatchdog) (supervisedExit, error) {
+	cmd := exec.Command(path)
+	cmd.Args = argv
+	cmd.Env = env
+
+	stdin := int(os.Stdin.Fd())
+	interactive := term.IsTerminal(stdin)
+
+	size := &pty.Winsize{Cols: 80, Rows: 24}
+	if interactive {
+		if s, err := pty.GetsizeFull(os.Stdin); err == nil {
+			size = s
+		}
+	}
+
+	start := time.Now()
+	ptmx, err := pty.StartWithSize(cmd, size)
+	if err != nil {
+		return supervisedExit{}, err
+	}
+	defer ptmx.Close()
+	pid := cmd.Process.Pid
+
+	if w != nil {
+		if err := w.WriteHeader(int(size.Cols), int(size.Rows)); err != nil {
+			DisplayWarning(fmt.Sprintf("写入录制失败: %v", err), DefaultTheme())
+		}
+	}
+
+	var state *term.State
+	if interactive {
+		if state, err = term.MakeRaw(stdin); err == nil {
+			defer term.Restore(stdin, state)
+		}
+	}
+	// 输入不写入录制，避免记录到手动输入的密钥
+	go func() {
+		_, _ = io.Copy(activityWriter{ptmx, watchdog}, os.Stdin)
+		// 管道输入结束时发送 EOF（Ctrl+D），使 claude -p 等读取标准输入的模式正常结束
+		if !interactive {
+			_, _ = ptmx.Write([]byte{4})
+		}
+	}()
+
+	sigs := make(chan os.Signal, 8)
+	signal.Notify(sigs, append(recordedSignals, syscall.SIGWINCH)...)
+	defer signal.Reset(append(recordedSignals, syscall.SIGWINCH)...)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				if sig == syscall.SIGWINCH {
+					if err := pty.InheritSize(os.Stdin, ptmx); err == nil {
+						if s, err := pty.GetsizeFull(ptmx); err == nil && w != nil {
+							_ = w.Resize(int(s.Cols), int(s.Rows))
+						}
+					}
+					continue
+				}
+				// pty 中的 claude 是会话首进程，向整个进程组转发
+				_ = syscall.Kill(-pid, sig.(syscall.Signal))
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer watchdog.watch(func(sig syscall.Signal) { _ = syscall.Kill(-pid, sig) })()
+
+	output := make(chan struct{})
+	go func() {
+		defer close(output)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := ptmx.Read(buf)
+			if n > 0 {
+				watchdog.touch()
+				_, _ = os.Stdout.Write(buf[:n])
+				if w != nil {
+					_ = w.Output(buf[:n])
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	ws, err := waitProcess(pid, func() {
+		if state != nil {
+			_ = term.Restore(stdin, state)
+		}
+		suspendSelf()
+		if state != nil {
+			_, _ = term.MakeRaw(stdin)
+			// 挂起期间终端尺寸可能已经变化
+			_ = pty.InheritSize(os.Stdin, ptmx)
+		}
+		_ = syscall.Kill(-pid, syscall.SIGCONT)
+	})
+	if err != nil {
+		return supervisedExit{}, err
+	}
+	_ = cmd.Process.Release()
+	// 子进程退出后读取 pty 中剩余的输出；后台进程仍占用终端时不再等待
+	select {
+	case <-output:
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	return exitStatus(ws, start), nil
+}
+
+// runRecorded 录制方式运行 claude，录制文件路径写入启动记录
+func runRecorded(req *launchRequest, path string, argv, env []string, entry *HistoryEntry, watchdog *sessionWatchdog) (supervisedExit, error) {
+	sessionPath := newSessionPath(req.Platform.Name, time.Now())
+	w, err := newCastWriter(sessionPath, req.Platform.Name, append(req.Secrets, req.Platform.AnthropicAuthToken))
+	if err != nil {
+		return supervisedExit{}, err
+	}
+
+	status, err := ptyProcess(path, argv, env, w, watchdog)
+	if cerr := w.Close(); cerr != nil {
+		DisplayWarning(fmt.Sprintf("保存录制失败: %v", cerr), DefaultTheme())
+	}
+	if err != nil {
+		_ = os.Remove(sessionPath)
+		return status, err
+	}
+
+	entry.Recording = sessionPath
+	fmt.Fprintf(os.Stderr, "→ 会话已录制到 %s（ccgate sessions play %s）\n", shortenHome(sessionPath), sessionID(sessionPath))
+	return status, nil
+}
@@ -0,0 +1,63 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRecordProcess tests recording a fake claude under a pty
+func TestRecordProcess(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	secret := "sk-secret-token-0123456789"
+	path := writeFakeClaude(t, `echo "model=$ANTHROPIC_MODEL token=$ANTHROPIC_AUTH_TOKEN"; sleep 0.1; echo done; exit 3`)
+
+	sessionPath := newSessionPath("prod", time.Now())
+	w, err := newCastWriter(sessionPath, "prod", []string{secret})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	status, err := ptyProcess(path, []string{"claude"}, []string{"ANTHROPIC_MODEL=m", "ANTHROPIC_AUTH_TOKEN=" + secret}, w, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Expected no error closing, got %v", err)
+	}
+	if status.ExitCode() != 3 {
+		t.Errorf("Expected exit code 3, got %+v", status)
+	}
+
+	info, err := os.Stat(sessionPath)
+	if err != nil {
+		t.Fatalf("Expected recording file, got %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	header, events, err := readSession(sessionPath)
+	if err != nil {
+		t.Fatalf("Expected no error reading, got %v", err)
+	}
+	if header.Version != 2 || header.Title != "prod" || header.Width != 80 || header.Height != 24 {
+		t.Errorf("Unexpected header %+v", header)
+	}
+
+	var output strings.Builder
+	playSession(&output, events, 1, 0, func(time.Duration) {})
+	got := output.String()
+	if strings.Contains(got, secret) {
+		t.Errorf("Expected token to be redacted, got %q", got)
+	}
+	if !strings.Contains(got, "model=m token="+redactedMarker) || !strings.Contains(got, "done") {
+		t.Errorf("Expected recorded output, got %q", got)
+	}
+	if len(events) < 2 || events[len(events)-1].Time <= events[0].Time {
+		t.Errorf("Expected increasing timestamps, got %+v", events)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResolutionSource 表示平台的选择依据
+type ResolutionSource string
+
+const (
+	SourceFlag        ResolutionSource = "flag"        // -p/--platform 参数
+	SourceEnv         ResolutionSource = "env"         // CCGATE_PLATFORM 环境变量
+	SourceDirectory   ResolutionSource = "directory"   // 目录绑定（ccgate use --local）
+	SourceRule        ResolutionSource = "rule"        // 配置中的 rules（目录、git 远程、分支）
+	SourceDefault     ResolutionSource = "default"     // 全局默认（ccgate use）
+	SourceSingle      ResolutionSource = "single"      // 唯一平台
+	SourceInteractive ResolutionSource = "interactive" // 交互式选择
+	SourceFallback    ResolutionSource = "fallback"    // 健康检查失败后切换的备用平台
+)
+
+// ResolutionStep 记录解析过程中检查过的一步
+type ResolutionStep struct {
+	Source  ResolutionSource
+	Matched bool
+	Detail  string
+}
+
+// Resolution 表示平台解析结果及其过程
+type Resolution struct {
+	Platform *Platform
+	Source   ResolutionSource
+	Detail   string
+	Steps    []ResolutionStep
+	// Rule 按规则选择时命中的规则
+	Rule *Rule
+}
+
+// sourceLabel 返回选择依据的显示名称
+func sourceLabel(source ResolutionSource) string {
+	switch source {
+	case SourceFlag:
+		return "-p/--platform"
+	case SourceEnv:
+		return shellPlatformEnv
+	case SourceDirectory:
+		return "目录绑定"
+	case SourceRule:
+		return "规则"
+	case SourceDefault:
+		return "默认平台"
+	case SourceSingle:
+		return "唯一平台"
+	case SourceInteractive:
+		return "交互式选择"
+	case SourceFallback:
+		return "备用平台"
+	default:
+		return string(source)
+	}
+}
+
+// Summary 返回一行选择依据说明
+func (r *Resolution) Summary() string {
+	if r.Platform == nil {
+		return "未能自动确定平台，需要交互式选择"
+	}
+	switch r.Source {
+	case SourceSingle:
+		return fmt.Sprintf("检测到唯一平台: %s，自动使用", r.Platform.Name)
+	case SourceInteractive:
+		return fmt.Sprintf("已交互式选择平台: %s", r.Platform.Name)
+	default:
+		return fmt.Sprintf("根据%s（%s）使用平台: %s", sourceLabel(r.Source), r.Detail, r.Platform.Name)
+	}
+}
+
+// resolvePlatform 按优先级自动确定平台，不进行任何交互
+// 顺序: -p 参数 > CCGATE_PLATFORM > 目录绑定 > 规则 > 默认平台 > 唯一平台
+// 均未命中时返回 Platform 为 nil 的结果，由调用方决定是否交互式选择
+func resolvePlatform(config *Config, flagName, cwd string) (*Resolution, error) {
+	res := &Resolution{}
+
+	// 记录一步并在命中时填充结果；引用了不存在平台的来源会被忽略
+	try := func(source ResolutionSource, name, detail string) bool {
+		if name == "" {
+			res.Steps = append(res.Steps, ResolutionStep{Source: source, Detail: detail})
+			return false
+		}
+		platform, err := findPlatformByName(config.Platforms, name)
+		if err != nil {
+			res.Steps = append(res.Steps, ResolutionStep{
+				Source: source,
+				Detail: fmt.Sprintf("%s → %s（平台不存在，已忽略）", detail, name),
+			})
+			return false
+		}
+		res.Platform = platform
+		res.Source = source
+		res.Detail = detail
+		res.Steps = append(res.Steps, ResolutionStep{
+			Source:  source,
+			Matched: true,
+			Detail:  fmt.Sprintf("%s → %s", detail, name),
+		})
+		return true
+	}
+
+	// -p/--platform 显式指定时平台必须存在
+	if flagName != "" {
+		if _, err := findPlatformByName(config.Platforms, flagName); err != nil {
+			return nil, unknownPlatformError(config.Platforms, flagName, err)
+		}
+		try(SourceFlag, flagName, "命令行参数")
+		return res, nil
+	}
+	try(SourceFlag, "", "未指定")
+
+	envName := os.Getenv(shellPlatformEnv)
+	envDetail := "未设置"
+	if envName != "" {
+		envDetail = "环境变量"
+	}
+	if try(SourceEnv, envName, envDetail) {
+		return res, nil
+	}
+
+	dir, bound := findDirectoryBinding(config.Directories, cwd)
+	dirDetail := "当前目录未绑定"
+	if dir != "" {
+		dirDetail = dir
+	}
+	if try(SourceDirectory, bound, dirDetail) {
+		return res, nil
+	}
+
+	// 规则按顺序匹配，引用了不存在平台的规则被忽略后继续匹配下一条
+	repo := &repoContext{cwd: cwd}
+	ruleMatched := false
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+		if !rule.matches(repo) {
+			continue
+		}
+		ruleMatched = true
+		if try(SourceRule, rule.Platform, fmt.Sprintf("#%d %s", i+1, rule)) {
+			res.Rule = rule
+			return res, nil
+		}
+	}
+	if !ruleMatched {
+		ruleDetail := "未配置"
+		if len(config.Rules) > 0 {
+			ruleDetail = fmt.Sprintf("%d 条规则均不匹配", len(config.Rules))
+		}
+		res.Steps = append(res.Steps, ResolutionStep{Source: SourceRule, Detail: ruleDetail})
+	}
+
+	defaultDetail := "未设置"
+	if config.Default != "" {
+		defaultDetail = "ccgate use"
+	}
+	if try(SourceDefault, config.Default, defaultDetail) {
+		return res, nil
+	}
+
+	if len(config.Platforms) == 1 {
+		try(SourceSingle, config.Platforms[0].Name, "仅配置了一个平台")
+		return res, nil
+	}
+	res.Steps = append(res.Steps, ResolutionStep{
+		Source: SourceSingle,
+		Detail: fmt.Sprintf("共有 %d 个平台", len(config.Platforms)),
+	})
+
+	res.Source = SourceInteractive
+	return res, nil
+}
+
+// findDirectoryBinding 从 cwd 开始逐级向上查找目录绑定
+// 返回命中的目录及其绑定的平台名称
+func findDirectoryBinding(bindings map[string]string, cwd string) (string, string) {
+	if len(bindings) == 0 || cwd == "" {
+		return "", ""
+	}
+	dir := filepath.Clean(cwd)
+	for {
+		if name, ok := bindings[dir]; ok {
+			return dir, name
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// unknownPlatformError 生成平台不存在的错误，并附带相似名称建议
+func unknownPlatformError(platforms []Platform, name string, err error) error {
+	suggestions := suggestPlatformNames(platforms, name)
+	if len(suggestions) > 0 {
+		return fmt.Errorf(
+			"平台 '%s' 不存在\n\n你是否想使用以下平台？\n  - %s\n\n运行 'ccgate list' 查看所有可用平台",
+			name,
+			strings.Join(suggestions, "\n  - "),
+		)
+	}
+	return fmt.Errorf("%w\n运行 'ccgate list' 查看所有可用平台", err)
+}
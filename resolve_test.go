@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestResolvePlatform tests the platform resolution order
+func TestResolvePlatform(t *testing.T) {
+	base := Config{
+		Platforms: []Platform{
+			{Name: "a", AnthropicBaseURL: "https://a", AnthropicAuthToken: "ta", AnthropicModel: "m"},
+			{Name: "b", AnthropicBaseURL: "https://b", AnthropicAuthToken: "tb", AnthropicModel: "m"},
+			{Name: "c", AnthropicBaseURL: "https://c", AnthropicAuthToken: "tc", AnthropicModel: "m"},
+		},
+	}
+	work := filepath.Join(string(filepath.Separator), "home", "u", "work")
+
+	tests := []struct {
+		name       string
+		flag       string
+		env        string
+		dirs       map[string]string
+		def        string
+		cwd        string
+		platforms  int
+		wantName   string
+		wantSource ResolutionSource
+		wantErr    bool
+	}{
+		{name: "flag wins", flag: "a", env: "b", def: "c", wantName: "a", wantSource: SourceFlag},
+		{name: "unknown flag", flag: "x", wantErr: true},
+		{name: "env over directory", env: "b", dirs: map[string]string{work: "c"}, cwd: work, wantName: "b", wantSource: SourceEnv},
+		{name: "directory from subdir", dirs: map[string]string{work: "c"}, def: "a", cwd: filepath.Join(work, "repo", "src"), wantName: "c", wantSource: SourceDirectory},
+		{name: "default", def: "b", cwd: work, wantName: "b", wantSource: SourceDefault},
+		{name: "stale default ignored", def: "gone", platforms: 1, wantName: "a", wantSource: SourceSingle},
+		{name: "single platform", platforms: 1, wantName: "a", wantSource: SourceSingle},
+		{name: "interactive", wantSource: SourceInteractive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(shellPlatformEnv, tt.env)
+
+			config := base
+			if tt.platforms > 0 {
+				config.Platforms = base.Platforms[:tt.platforms]
+			}
+			config.Directories = tt.dirs
+			config.Default = tt.def
+
+			res, err := resolvePlatform(&config, tt.flag, tt.cwd)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if res.Source != tt.wantSource {
+				t.Errorf("Expected source %s, got %s", tt.wantSource, res.Source)
+			}
+			if tt.wantName == "" {
+				if res.Platform != nil {
+					t.Errorf("Expected no platform, got %s", res.Platform.Name)
+				}
+				return
+			}
+			if res.Platform == nil || res.Platform.Name != tt.wantName {
+				t.Errorf("Expected platform %s, got %+v", tt.wantName, res.Platform)
+			}
+		})
+	}
+}
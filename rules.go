@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Rule 按工作目录、git 远程地址或分支自动选择平台
+// 同一条规则中设置的条件需要全部满足，规则按配置顺序匹配
+type Rule struct {
+	// Dir 工作目录的 glob（支持 ~），当前目录或其任一上级目录匹配即可
+	Dir string `json:"dir,omitempty"`
+	// Remote git 远程地址的模式，任一远程匹配即可；* 匹配任意字符（包括 /）
+	Remote string `json:"remote,omitempty"`
+	// Branch 当前分支名的模式，如 release/*
+	Branch   string `json:"branch,omitempty"`
+	Platform string `json:"platform"`
+}
+
+// Validate 验证规则是否有效
+func (r *Rule) Validate() error {
+	if r.Platform == "" {
+		return fmt.Errorf("缺少 platform")
+	}
+	if r.Dir == "" && r.Remote == "" && r.Branch == "" {
+		return fmt.Errorf("至少需要 dir、remote、branch 中的一个条件")
+	}
+	if _, err := filepath.Match(expandHome(r.Dir), ""); err != nil {
+		return fmt.Errorf("dir 模式无效: %s", r.Dir)
+	}
+	return nil
+}
+
+// String 返回规则条件的简要说明，如 "dir=~/work/clientA remote=*clientA*"
+func (r *Rule) String() string {
+	var parts []string
+	if r.Dir != "" {
+		parts = append(parts, "dir="+r.Dir)
+	}
+	if r.Remote != "" {
+		parts = append(parts, "remote="+r.Remote)
+	}
+	if r.Branch != "" {
+		parts = append(parts, "branch="+r.Branch)
+	}
+	return strings.Join(parts, " ")
+}
+
+// repoContext 规则匹配所需的目录和 git 信息，git 信息在第一次需要时获取
+type repoContext struct {
+	cwd     string
+	loaded  bool
+	remotes []string
+	branch  string
+}
+
+// git 返回 cwd 所在仓库的远程地址和当前分支，不在仓库中时均为空
+func (c *repoContext) git() ([]string, string) {
+	if c.loaded {
+		return c.remotes, c.branch
+	}
+	c.loaded = true
+	if c.cwd == "" {
+		return nil, ""
+	}
+
+	cmd := exec.Command("git", "config", "--get-regexp", `^remote\..*\.url$`)
+	cmd.Dir = c.cwd
+	if out, err := cmd.Output(); err == nil {
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if _, url, ok := strings.Cut(line, " "); ok {
+				c.remotes = append(c.remotes, url)
+			}
+		}
+	}
+
+	cmd = exec.Command("git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	cmd.Dir = c.cwd
+	if out, err := cmd.Output(); err == nil {
+		c.branch = strings.TrimSpace(string(out))
+	}
+	return c.remotes, c.branch
+}
+
+// matches 判断规则是否匹配
+func (r *Rule) matches(ctx *repoContext) bool {
+	if r.Dir != "" && !dirGlobMatch(expandHome(r.Dir), ctx.cwd) {
+		return false
+	}
+	if r.Remote == "" && r.Branch == "" {
+		return true
+	}
+
+	remotes, branch := ctx.git()
+	if r.Remote != "" {
+		matched := false
+		for _, url := range remotes {
+			if wildcardMatch(r.Remote, url) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return r.Branch == "" || (branch != "" && wildcardMatch(r.Branch, branch))
+}
+
+// dirGlobMatch 判断 dir 或其任一上级目录是否匹配 pattern
+func dirGlobMatch(pattern, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	pattern = filepath.Clean(pattern)
+	dir = filepath.Clean(dir)
+	for {
+		if ok, _ := filepath.Match(pattern, dir); ok {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// wildcardMatch 判断 s 是否完整匹配 pattern，* 匹配任意字符序列，? 匹配单个字符
+func wildcardMatch(pattern, s string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	ok, _ := regexp.MatchString("^"+expr+"$", s)
+	return ok
+}
+
+// expandHome 将开头的 ~ 展开为用户主目录
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initGitRepo 在临时目录中创建带远程地址的 git 仓库并切换到 branch
+func initGitRepo(t *testing.T, remote, branch string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"remote", "add", "origin", remote},
+		{"checkout", "-q", "-b", branch},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+// TestResolvePlatformRules tests rule matching and its place in the resolution order
+func TestResolvePlatformRules(t *testing.T) {
+	t.Setenv(shellPlatformEnv, "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	clientDir := filepath.Join(home, "work", "clientA", "api")
+	if err := os.MkdirAll(clientDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	repo := initGitRepo(t, "git@github.com:clientB/app.git", "release/1.2")
+
+	config := &Config{
+		Platforms: []Platform{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}},
+		Default:   "d",
+		Rules: []Rule{
+			{Dir: "~/work/client*", Platform: "a"},
+			{Remote: "*github.com*clientB/*", Branch: "main", Platform: "gone"},
+			{Remote: "*github.com*clientB/*", Branch: "release/*", Platform: "missing"},
+			{Remote: "*clientB*", Platform: "b"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		cwd        string
+		dirs       map[string]string
+		wantName   string
+		wantSource ResolutionSource
+		wantDetail string
+	}{
+		{name: "dir glob matches subdirectory", cwd: clientDir, wantName: "a", wantSource: SourceRule, wantDetail: "#1 dir=~/work/client*"},
+		{name: "missing platform skipped", cwd: repo, wantName: "b", wantSource: SourceRule, wantDetail: "#4 remote=*clientB*"},
+		{name: "directory binding wins", cwd: clientDir, dirs: map[string]string{clientDir: "c"}, wantName: "c", wantSource: SourceDirectory},
+		{name: "default when no rule matches", cwd: home, wantName: "d", wantSource: SourceDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Directories = tt.dirs
+			res, err := resolvePlatform(config, "", tt.cwd)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if res.Platform == nil || res.Platform.Name != tt.wantName || res.Source != tt.wantSource {
+				t.Fatalf("Expected %s from %s, got %+v", tt.wantName, tt.wantSource, res)
+			}
+			if tt.wantSource == SourceRule && (res.Detail != tt.wantDetail || res.Rule == nil) {
+				t.Errorf("Expected detail %q with rule, got %q (%+v)", tt.wantDetail, res.Detail, res.Rule)
+			}
+		})
+	}
+}
+
+// TestWildcardMatch tests remote and branch patterns
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*github.com*clientA/*", "git@github.com:clientA/app.git", true},
+		{"*github.com*clientA/*", "https://github.com/clientA/app", true},
+		{"*github.com*clientA/*", "https://github.com/clientAB/app", false},
+		{"release/*", "release/1.2", true},
+		{"release/*", "hotfix/release/1.2", false},
+		{"feat-?", "feat-1", true},
+		{"a.b", "axb", false},
+	}
+	for _, tt := range tests {
+		if got := wildcardMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("wildcardMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+
+	if !dirGlobMatch("/work/*/infra", "/work/x/infra/modules") || dirGlobMatch("/work/*/infra", "/work/infra") {
+		t.Error("Unexpected dirGlobMatch result")
+	}
+}
+
+// TestRuleValidate tests rule validation in config
+func TestRuleValidate(t *testing.T) {
+	platforms := []Platform{{Name: "a", AnthropicBaseURL: "https://a", AnthropicAuthToken: "t", AnthropicModel: "m"}}
+	for _, tt := range []struct {
+		rule    Rule
+		wantErr bool
+	}{
+		{Rule{Dir: "~/work", Platform: "a"}, false},
+		{Rule{Branch: "main"}, true},
+		{Rule{Platform: "a"}, true},
+		{Rule{Dir: "/work/[", Platform: "a"}, true},
+		{Rule{Remote: "*", Platform: "x"}, true},
+	} {
+		config := &Config{Platforms: platforms, Rules: []Rule{tt.rule}}
+		if err := config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: expected error=%v, got %v", tt.rule, tt.wantErr, err)
+		}
+	}
+}
+
+// TestInvalidConfigRefusesLaunch tests that an invalid rule is reported at launch instead of being skipped
+func TestInvalidConfigRefusesLaunch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := &Config{
+		Platforms: []Platform{{Name: "a", AnthropicBaseURL: "https://a", AnthropicAuthToken: "t", AnthropicModel: "m"}},
+		Rules:     []Rule{{Platform: "a"}},
+	}
+	if err := saveConfig(config, path); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"-f", path, "--dry-run"},
+		{"exec", "-f", path, "-p", "a", "--", "true"},
+		{"shell", "-f", path, "-p", "a"},
+		{"fanout", "-f", path, "-p", "a", "hi"},
+	} {
+		_, stderr, code := runCCGate(t, args...)
+		if code == 0 || !strings.Contains(stderr, "规则 1") {
+			t.Errorf("%q: expected invalid rule to be reported, got code %d, stderr %q", args, code, stderr)
+		}
+	}
+}
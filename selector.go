@@ -12,9 +12,10 @@ import (
 )
 
 // selectPlatform 选择平台（自动或交互式）
+// 先按 resolvePlatform 的优先级自动确定，均未命中时再交互式选择
 // claudeArgs 用于判断是否需要显示提示信息
 // skipConfirm 是否跳过确认（用于 --yes 参数）
-func selectPlatform(config *Config, platformName string, claudeArgs []string, skipConfirm bool) (*Platform, error) {
+func selectPlatform(config *Config, platformName string, claudeArgs []string, skipConfirm bool) (*Resolution, error) {
 	if len(config.Platforms) == 0 {
 		theme := DefaultTheme()
 		err := NewUserError("没有配置任何平台", "请先运行 'ccgate add' 添加平台")
@@ -22,36 +23,18 @@ func selectPlatform(config *Config, platformName string, claudeArgs []string, sk
 		return nil, fmt.Errorf("没有配置任何平台")
 	}
 
-	// 情况1: 通过 -p/--platform 显式指定
-	if platformName != "" {
-		platform, err := findPlatformByName(config.Platforms, platformName)
-		if err != nil {
-			// 提供模糊匹配建议
-			suggestions := suggestPlatformNames(config.Platforms, platformName)
-			if len(suggestions) > 0 {
-				return nil, fmt.Errorf(
-					"平台 '%s' 不存在\n\n你是否想使用以下平台？\n  - %s\n\n运行 'ccgate list' 查看所有可用平台",
-					platformName,
-					strings.Join(suggestions, "\n  - "),
-				)
-			}
-			return nil, fmt.Errorf("%w\n运行 'ccgate list' 查看所有可用平台", err)
-		}
-		return platform, nil
+	cwd, _ := os.Getwd()
+	res, err := resolvePlatform(config, platformName, cwd)
+	if err != nil {
+		return nil, err
 	}
 
-	// 情况2: 只有一个平台，自动使用
-	if len(config.Platforms) == 1 {
-		platform := &config.Platforms[0]
-		if len(claudeArgs) > 0 {
-			theme := DefaultTheme()
-			msg := fmt.Sprintf("检测到唯一平台: %s，自动使用", platform.Name)
-			DisplayInfo(msg, theme)
-		}
-		return platform, nil
+	// 情况1: -p、环境变量、目录绑定、规则、默认平台或唯一平台已确定
+	if res.Platform != nil {
+		return res, nil
 	}
 
-	// 情况3: 多个平台，需要交互式选择
+	// 情况2: 多个平台，需要交互式选择
 	// 检查是否支持交互（TTY）
 	if !term.IsTerminal(int(os.Stdin.Fd())) {
 		return nil, formatNonInteractiveError(config.Platforms, claudeArgs)
@@ -65,9 +48,11 @@ func selectPlatform(config *Config, platformName string, claudeArgs []string, sk
 			return nil, err
 		}
 
+		res.Platform = platform
+
 		// 跳过确认（--yes 参数）
 		if skipConfirm {
-			return platform, nil
+			return res, nil
 		}
 
 		// 确认执行，如果取消则返回重新选择
@@ -83,7 +68,7 @@ func selectPlatform(config *Config, platformName string, claudeArgs []string, sk
 		}
 
 		// 确认通过，返回选中的平台
-		return platform, nil
+		return res, nil
 	}
 }
 
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	playSpeed   float64
+	playMaxIdle time.Duration
+)
+
+// sessions 子命令组
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "浏览和回放 --record 录制的会话",
+	Long: `浏览和回放通过 'ccgate --record' 录制的会话。
+
+录制文件为 gzip 压缩的 asciicast v2 格式，保存在 ~/.ccgate/sessions/，
+解压后也可以使用 asciinema play 回放。
+
+示例:
+  ccgate sessions list
+  ccgate sessions play                 # 回放最近一次会话
+  ccgate sessions play 20250101-1504 --speed 2`,
+	Args: cobra.NoArgs,
+}
+
+// sessions list 子命令
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出录制的会话",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessions, err := listSessions()
+		if err != nil {
+			return err
+		}
+		if structuredOutput() {
+			if sessions == nil {
+				sessions = []sessionInfo{}
+			}
+			return writeStructured(sessions)
+		}
+		printSessions(sessions)
+		return nil
+	},
+}
+
+// sessions play 子命令
+var sessionsPlayCmd = &cobra.Command{
+	Use:   "play [id]",
+	Short: "在终端中回放会话（省略 ID 时回放最近一次）",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if playSpeed <= 0 {
+			return NewValidationError("--speed 必须大于 0", "示例: --speed 2")
+		}
+		id := ""
+		if len(args) > 0 {
+			id = args[0]
+		}
+		session, err := findSession(id)
+		if err != nil {
+			return err
+		}
+		header, events, err := readSession(session.Path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "→ 回放 %s（平台 %s，%dx%d）\n", session.ID, header.Title, header.Width, header.Height)
+		playSession(os.Stdout, events, playSpeed, playMaxIdle, time.Sleep)
+		fmt.Fprintln(os.Stderr)
+		return nil
+	},
+}
+
+func init() {
+	sessionsPlayCmd.Flags().Float64Var(&playSpeed, "speed", 1, "回放速度倍数")
+	sessionsPlayCmd.Flags().DurationVar(&playMaxIdle, "max-idle", 2*time.Second, "压缩超过该时长的停顿（0 表示不压缩）")
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsPlayCmd)
+}
+
+// sessionInfo 录制会话的摘要
+type sessionInfo struct {
+	ID         string    `json:"id" yaml:"id"`
+	Platform   string    `json:"platform" yaml:"platform"`
+	Time       time.Time `json:"time" yaml:"time"`
+	DurationMS int64     `json:"duration_ms" yaml:"duration_ms"`
+	Size       int64     `json:"size" yaml:"size"`
+	Path       string    `json:"path" yaml:"path"`
+}
+
+// castEvent asciicast v2 的事件：[时间, 类型, 数据]
+type castEvent struct {
+	Time float64
+	Kind string
+	Data string
+}
+
+// UnmarshalJSON 解析数组形式的事件
+func (e *castEvent) UnmarshalJSON(data []byte) error {
+	var raw []any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("事件格式无效: %s", data)
+	}
+	t, ok1 := raw[0].(float64)
+	kind, ok2 := raw[1].(string)
+	text, ok3 := raw[2].(string)
+	if !ok1 || !ok2 || !ok3 {
+		return fmt.Errorf("事件格式无效: %s", data)
+	}
+	e.Time, e.Kind, e.Data = t, kind, text
+	return nil
+}
+
+// sessionID 返回录制文件对应的会话 ID
+func sessionID(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), sessionExt)
+}
+
+// readSession 读取录制文件的头部和全部事件
+func readSession(path string) (castHeader, []castEvent, error) {
+	var header castHeader
+	f, err := os.Open(path)
+	if err != nil {
+		return header, nil, fmt.Errorf("打开录制文件失败: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return header, nil, fmt.Errorf("录制文件 %s 无效: %w", path, err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		return header, nil, fmt.Errorf("录制文件 %s 为空", path)
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return header, nil, fmt.Errorf("录制文件 %s 的头部无效: %w", path, err)
+	}
+
+	var events []castEvent
+	for scanner.Scan() {
+		var e castEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// 异常退出时最后一行可能不完整，保留之前的事件
+			break
+		}
+		events = append(events, e)
+	}
+	// 同理，未正常关闭的 gzip 流读到末尾时会报错，此时返回已读取的事件
+	return header, events, nil
+}
+
+// readSessionHeader 只读取录制文件的第一行（头部），不解压后续事件
+func readSessionHeader(path string) (castHeader, error) {
+	var header castHeader
+	f, err := os.Open(path)
+	if err != nil {
+		return header, fmt.Errorf("打开录制文件失败: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return header, fmt.Errorf("录制文件 %s 无效: %w", path, err)
+	}
+	defer gz.Close()
+
+	line, err := bufio.NewReader(gz).ReadBytes('\n')
+	if len(line) == 0 {
+		return header, fmt.Errorf("录制文件 %s 为空", path)
+	}
+	if err := json.Unmarshal(line, &header); err != nil {
+		return header, fmt.Errorf("录制文件 %s 的头部无效: %w", path, err)
+	}
+	return header, nil
+}
+
+// sessionFiles 返回录制目录中的录制文件名，不打开文件
+func sessionFiles() ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(sessionsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取录制目录失败: %w", err)
+	}
+	var files []os.DirEntry
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), sessionExt) {
+			files = append(files, entry)
+		}
+	}
+	return files, nil
+}
+
+// listSessions 列出全部录制，最新的在前
+// 只读取每个文件的头部；时长取文件最后修改时间与开始时间之差（录制结束时最后一次写入），精确到秒
+func listSessions() ([]sessionInfo, error) {
+	files, err := sessionFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []sessionInfo
+	for _, entry := range files {
+		path := filepath.Join(sessionsDir(), entry.Name())
+		header, err := readSessionHeader(path)
+		if err != nil {
+			continue
+		}
+		info := sessionInfo{
+			ID:       sessionID(path),
+			Platform: header.Title,
+			Time:     time.Unix(header.Timestamp, 0),
+			Path:     path,
+		}
+		if fi, err := entry.Info(); err == nil {
+			info.Size = fi.Size()
+			if d := fi.ModTime().Sub(info.Time); d > 0 {
+				info.DurationMS = d.Milliseconds()
+			}
+		}
+		sessions = append(sessions, info)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID > sessions[j].ID })
+	return sessions, nil
+}
+
+// findSession 按 ID 或 ID 前缀查找录制，id 为空时返回最近一次
+func findSession(id string) (*sessionInfo, error) {
+	sessions, err := listSessions()
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, NewUserError("还没有录制的会话", "使用 'ccgate --record' 启动 claude 以录制会话")
+	}
+	if id == "" {
+		return &sessions[0], nil
+	}
+
+	var matches []*sessionInfo
+	for i := range sessions {
+		if sessions[i].ID == id {
+			return &sessions[i], nil
+		}
+		if strings.HasPrefix(sessions[i].ID, id) {
+			matches = append(matches, &sessions[i])
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, NewValidationError(fmt.Sprintf("会话 '%s' 不存在", id), "运行 'ccgate sessions list' 查看所有录制")
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID
+	}
+	return nil, NewValidationError(
+		fmt.Sprintf("'%s' 匹配多个会话: %s", id, strings.Join(ids, ", ")),
+		"请输入更完整的会话 ID")
+}
+
+// playSession 按录制时的节奏输出事件，停顿超过 maxIdle 时压缩为 maxIdle
+func playSession(w io.Writer, events []castEvent, speed float64, maxIdle time.Duration, sleep func(time.Duration)) {
+	last := 0.0
+	for _, e := range events {
+		delay := time.Duration((e.Time - last) * float64(time.Second))
+		last = e.Time
+		if maxIdle > 0 && delay > maxIdle {
+			delay = maxIdle
+		}
+		if delay > 0 {
+			sleep(time.Duration(float64(delay) / speed))
+		}
+		if e.Kind == "o" {
+			_, _ = io.WriteString(w, e.Data)
+		}
+	}
+}
+
+// printSessions 以表格显示录制列表
+func printSessions(sessions []sessionInfo) {
+	theme := DefaultTheme()
+	if len(sessions) == 0 {
+		DisplayInfo("还没有录制的会话，使用 'ccgate --record' 启动 claude 以录制会话", theme)
+		return
+	}
+
+	tableData := pterm.TableData{{"ID", "平台", "时间", "时长", "大小"}}
+	for _, s := range sessions {
+		tableData = append(tableData, []string{
+			s.ID,
+			theme.Colors.Primary.Sprint(s.Platform),
+			s.Time.Local().Format("2006-01-02 15:04"),
+			(time.Duration(s.DurationMS) * time.Millisecond).Round(time.Second).String(),
+			fmt.Sprintf("%.1f KB", float64(s.Size)/1024),
+		})
+	}
+	pterm.DefaultTable.WithHasHeader(true).
+		WithBoxed(true).
+		WithData(tableData).
+		Render()
+	Spacer(theme.Spacing.XS, theme)
+}
+
+// completeSessionArg 补全会话 ID，只列出文件名，不读取录制内容
+func completeSessionArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	files, _ := sessionFiles()
+	var ids []string
+	for _, entry := range files {
+		if id := sessionID(entry.Name()); strings.HasPrefix(id, toComplete) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
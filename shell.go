@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// shellPlatformEnv 在 ccgate shell 中标记当前平台的环境变量
+const shellPlatformEnv = "CCGATE_PLATFORM"
+
+// shell 子命令
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "启动带有平台环境变量的子 shell",
+	Long: `启动一个应用了平台环境变量的子 shell（使用 $SHELL）。
+
+在该 shell 中多次运行 claude 都会使用同一个平台，提示符前会显示
+平台名称，并设置 CCGATE_PLATFORM 环境变量。退出 shell 后会显示
+本次会话的平台和时长。
+
+示例:
+  ccgate shell -p prod`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		if err := checkConfig(config); err != nil {
+			return err
+		}
+
+		res, err := selectPlatform(config, platformName, nil, true)
+		if err != nil {
+			return err
+		}
+
+		policy, err := resolveEnvPolicy(config, res.Platform)
+		if err != nil {
+			return err
+		}
+		return runPlatformShell(res.Platform, policy)
+	},
+}
+
+func init() {
+	shellCmd.Flags().StringVarP(&platformName, "platform", "p", "", "指定平台名称")
+}
+
+// runPlatformShell 启动子 shell 并在退出后显示会话摘要
+func runPlatformShell(platform *Platform, policy envPolicy) error {
+	theme := DefaultTheme()
+
+	// 检测嵌套的 ccgate shell
+	if current := os.Getenv(shellPlatformEnv); current != "" {
+		DisplayWarning(fmt.Sprintf("当前已处于 ccgate shell（平台: %s）中，将嵌套启动新的 shell", current), theme)
+	}
+
+	shellPath := userShell()
+	args, extraEnv, cleanup, err := shellInvocation(shellPath, platform.Name)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	env, err := platformEnviron(platform, policy)
+	if err != nil {
+		return err
+	}
+	env = append(env, shellPlatformEnv+"="+platform.Name)
+	env = append(env, extraEnv...)
+
+	c := exec.Command(shellPath, args...)
+	c.Env = env
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	DisplayInfo(fmt.Sprintf("进入 ccgate shell（平台: %s），输入 exit 退出", platform.Name), theme)
+
+	// Ctrl+C 交给子 shell 处理，ccgate 自身不退出
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	start := time.Now()
+	runErr := c.Run()
+	duration := time.Since(start).Round(time.Second)
+
+	DisplayInfo(fmt.Sprintf("已退出 ccgate shell（平台: %s，时长: %s）", platform.Name, duration), theme)
+
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		cleanup()
+		os.Exit(exitErr.ExitCode())
+	}
+	if runErr != nil {
+		return fmt.Errorf("启动 shell 失败: %w", runErr)
+	}
+	return nil
+}
+
+// userShell 返回用户的默认 shell
+func userShell() string {
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return sh
+	}
+	if runtime.GOOS == "windows" {
+		if comspec := os.Getenv("COMSPEC"); comspec != "" {
+			return comspec
+		}
+		return "cmd.exe"
+	}
+	return "/bin/sh"
+}
+
+// shellInvocation 根据 shell 类型生成启动参数和额外环境变量，用于设置提示符前缀
+// 返回的 cleanup 用于删除临时生成的启动文件，可重复调用
+func shellInvocation(shellPath, name string) ([]string, []string, func(), error) {
+	prefix := fmt.Sprintf("(ccgate:%s) ", name)
+	noop := func() {}
+
+	switch filepath.Base(shellPath) {
+	case "bash":
+		dir, err := os.MkdirTemp("", "ccgate-shell-")
+		if err != nil {
+			return nil, nil, noop, fmt.Errorf("创建临时目录失败: %w", err)
+		}
+		rc := filepath.Join(dir, "bashrc")
+		content := fmt.Sprintf("[ -f ~/.bashrc ] && . ~/.bashrc\nPS1=%s\"$PS1\"\n", shellQuote(prefix))
+		if err := os.WriteFile(rc, []byte(content), 0o600); err != nil {
+			os.RemoveAll(dir)
+			return nil, nil, noop, fmt.Errorf("写入临时 bashrc 失败: %w", err)
+		}
+		return []string{"--rcfile", rc, "-i"}, nil, func() { os.RemoveAll(dir) }, nil
+
+	case "zsh":
+		dir, err := os.MkdirTemp("", "ccgate-shell-")
+		if err != nil {
+			return nil, nil, noop, fmt.Errorf("创建临时目录失败: %w", err)
+		}
+		origDir := os.Getenv("ZDOTDIR")
+		if origDir == "" {
+			origDir, _ = os.UserHomeDir()
+		}
+		// 通过临时 ZDOTDIR 先加载用户原有配置，再添加提示符前缀
+		files := map[string]string{
+			".zshenv": fmt.Sprintf("[ -f %[1]s/.zshenv ] && . %[1]s/.zshenv\n", shellQuote(origDir)),
+			".zshrc": fmt.Sprintf("ZDOTDIR=%s\n[ -f \"$ZDOTDIR/.zshrc\" ] && . \"$ZDOTDIR/.zshrc\"\nPROMPT=%s\"$PROMPT\"\n",
+				shellQuote(origDir), shellQuote(prefix)),
+		}
+		for file, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o600); err != nil {
+				os.RemoveAll(dir)
+				return nil, nil, noop, fmt.Errorf("写入临时 %s 失败: %w", file, err)
+			}
+		}
+		return []string{"-i"}, []string{"ZDOTDIR=" + dir}, func() { os.RemoveAll(dir) }, nil
+
+	case "fish":
+		prompt := fmt.Sprintf("functions -c fish_prompt _ccgate_fish_prompt; "+
+			"function fish_prompt; echo -n %s; _ccgate_fish_prompt; end", fishQuote(prefix))
+		return []string{"-i", "-C", prompt}, nil, noop, nil
+
+	default:
+		return nil, []string{"PS1=" + prefix + os.Getenv("PS1")}, noop, nil
+	}
+}
+
+// shellQuote 用单引号包裹 s，使 sh/bash/zsh 不对其中的 $、` 等做任何展开
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote 用单引号包裹 s；fish 的单引号中只有 \\ 和 \' 是转义
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sourceAndPrint sources rc with sh and prints the given variables, one per line
+func sourceAndPrint(t *testing.T, rc string, vars ...string) []string {
+	t.Helper()
+	script := `. "$1"`
+	for _, v := range vars {
+		script += `; printf '%s\n' "$` + v + `"`
+	}
+	cmd := exec.Command("sh", "-c", script, "sh", rc)
+	cmd.Env = []string{"HOME=" + t.TempDir(), "PATH=" + os.Getenv("PATH"), "PS1=", "PROMPT="}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to source %s: %v\n%s", rc, err, out)
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+// TestShellInvocation tests that platform names and ZDOTDIR are never expanded by the shell
+func TestShellInvocation(t *testing.T) {
+	name := "a$(touch pwned)`id`'b\\é"
+	prefix := "(ccgate:" + name + ") "
+	zdotdir := filepath.Join(t.TempDir(), "$HOME dir")
+	t.Setenv("ZDOTDIR", zdotdir)
+	t.Chdir(t.TempDir())
+
+	args, env, cleanup, err := shellInvocation("/bin/bash", name)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer cleanup()
+	if len(args) != 3 || args[0] != "--rcfile" || env != nil {
+		t.Fatalf("Unexpected bash invocation %q %q", args, env)
+	}
+	if got := sourceAndPrint(t, args[1], "PS1"); got[0] != prefix {
+		t.Errorf("Expected bash PS1 %q, got %q", prefix, got[0])
+	}
+
+	args, env, cleanup, err = shellInvocation("/usr/bin/zsh", name)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer cleanup()
+	if len(args) != 1 || len(env) != 1 || !strings.HasPrefix(env[0], "ZDOTDIR=") {
+		t.Fatalf("Unexpected zsh invocation %q %q", args, env)
+	}
+	rc := filepath.Join(strings.TrimPrefix(env[0], "ZDOTDIR="), ".zshrc")
+	if got := sourceAndPrint(t, rc, "ZDOTDIR", "PROMPT"); got[0] != zdotdir || got[1] != prefix {
+		t.Errorf("Expected ZDOTDIR %q and PROMPT %q, got %q", zdotdir, prefix, got)
+	}
+	if _, err := os.Stat("pwned"); err == nil {
+		t.Error("Expected platform name not to be executed")
+	}
+
+	args, _, _, _ = shellInvocation("/usr/bin/fish", "it's")
+	if !strings.Contains(args[2], `echo -n '(ccgate:it\'s) '`) {
+		t.Errorf("Unexpected fish prompt %q", args[2])
+	}
+}
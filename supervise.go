@@ -0,0 +1,23 @@
+package main
+
+import (
+	"syscall"
+	"time"
+)
+
+// supervisedExit 托管模式下子进程的退出状态
+type supervisedExit struct {
+	// Code 正常退出时的退出码
+	Code int
+	// Signal 被信号终止时的信号，正常退出时为 0
+	Signal   syscall.Signal
+	Duration time.Duration
+}
+
+// ExitCode 返回 shell 约定的退出码，被信号终止时为 128+信号值
+func (e supervisedExit) ExitCode() int {
+	if e.Signal != 0 {
+		return 128 + int(e.Signal)
+	}
+	return e.Code
+}
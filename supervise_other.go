@@ -0,0 +1,18 @@
+//go:build !unix
+
+package main
+
+// checkSupervised 托管模式依赖进程组、信号转发和 wait4，仅支持类 Unix 系统
+func checkSupervised(req *launchRequest) error {
+	if req.Record {
+		return errRecordUnsupported()
+	}
+	return NewUserError(
+		"当前系统不支持托管模式",
+		"--supervise、supervise 设置、post_launch 钩子以及 max_session/idle_timeout 仅在 Linux、macOS 等类 Unix 系统上可用，请去掉这些设置后重试")
+}
+
+// runSupervised 不支持托管模式的系统上直接返回错误
+func runSupervised(req *launchRequest, path string, argv, env []string, entry HistoryEntry) error {
+	return checkSupervised(req)
+}
@@ -0,0 +1,104 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// TestSuperviseProcessExitCode tests that the child's exit code and environment are propagated
+func TestSuperviseProcessExitCode(t *testing.T) {
+	path := writeFakeClaude(t, `[ "$ANTHROPIC_MODEL" = "m" ] || exit 1; exit 3`)
+
+	status, err := superviseProcess(path, []string{"claude"}, []string{"ANTHROPIC_MODEL=m"}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.Code != 3 || status.Signal != 0 || status.ExitCode() != 3 {
+		t.Errorf("Expected exit code 3, got %+v", status)
+	}
+	if status.Duration <= 0 {
+		t.Errorf("Expected positive duration, got %v", status.Duration)
+	}
+}
+
+// TestSuperviseProcessSignaled tests the exit status of a child killed by a signal
+func TestSuperviseProcessSignaled(t *testing.T) {
+	path := writeFakeClaude(t, `kill -TERM $$`)
+
+	status, err := superviseProcess(path, []string{"claude"}, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.Signal != syscall.SIGTERM || status.ExitCode() != 128+int(syscall.SIGTERM) {
+		t.Errorf("Expected termination by SIGTERM, got %+v", status)
+	}
+}
+
+// TestSuperviseProcessForwardsSignals tests that signals sent to ccgate reach the child
+func TestSuperviseProcessForwardsSignals(t *testing.T) {
+	ready := filepath.Join(t.TempDir(), "ready")
+	path := writeFakeClaude(t, `trap 'exit 7' TERM; touch "$1"; while :; do sleep 0.05; done`)
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			if _, err := os.Stat(ready); err == nil {
+				syscall.Kill(os.Getpid(), syscall.SIGTERM)
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	status, err := superviseProcess(path, []string{"claude", ready}, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.Code != 7 {
+		t.Errorf("Expected the child to handle the forwarded SIGTERM and exit 7, got %+v", status)
+	}
+}
+
+// TestSuperviseProcessSharedGroupSignals tests that group signals are not forwarded when the child shares ccgate's process group
+func TestSuperviseProcessSharedGroupSignals(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("标准输入是终端时子进程使用独立的进程组")
+	}
+	dir := t.TempDir()
+	ready, log := filepath.Join(dir, "ready"), filepath.Join(dir, "log")
+	path := writeFakeClaude(t, `trap 'echo int >> "$2"' INT; trap 'exit 7' TERM; echo $$ > "$1.tmp"; mv "$1.tmp" "$1"; while :; do sleep 0.05; done`)
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			if data, err := os.ReadFile(ready); err == nil {
+				pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+				// 模拟终端的 Ctrl+C：ccgate 和同组的子进程各收到一次
+				syscall.Kill(os.Getpid(), syscall.SIGINT)
+				syscall.Kill(pid, syscall.SIGINT)
+				time.Sleep(300 * time.Millisecond)
+				syscall.Kill(os.Getpid(), syscall.SIGTERM)
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	status, err := superviseProcess(path, []string{"claude", ready, log}, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.Code != 7 {
+		t.Errorf("Expected the forwarded SIGTERM to end the child, got %+v", status)
+	}
+	if data, _ := os.ReadFile(log); string(data) != "int\n" {
+		t.Errorf("Expected the child to receive SIGINT exactly once, got %q", data)
+	}
+}
@@ -0,0 +1,226 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// checkSupervised 类 Unix 系统支持全部托管功能
+func checkSupervised(req *launchRequest) error {
+	return nil
+}
+
+// forwardedSignals 托管模式下转发给子进程的信号
+// 终端产生的 SIGINT、SIGQUIT、SIGTSTP、SIGWINCH 会直接发给前台的子进程组，
+// 这里转发的是通过 kill 发给 ccgate 自身的信号
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGWINCH,
+	syscall.SIGTSTP,
+	syscall.SIGCONT,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// groupSignals 终端按键或 shell 作业控制发给整个前台进程组的信号
+// 子进程与 ccgate 同属一个进程组时已直接收到，转发会使其收到两次
+var groupSignals = map[os.Signal]bool{
+	syscall.SIGINT:   true,
+	syscall.SIGQUIT:  true,
+	syscall.SIGTSTP:  true,
+	syscall.SIGWINCH: true,
+	syscall.SIGCONT:  true,
+}
+
+// superviseProcess 以子进程方式运行命令并等待其退出
+//
+// 标准输入是终端时，子进程放入独立的进程组并设为终端的前台进程组，
+// 使 Ctrl+C、Ctrl+Z 等只作用于子进程；子进程被挂起时 ccgate 收回终端并挂起自身，
+// 恢复（fg）后再将终端交还子进程并发送 SIGCONT。
+// 否则子进程留在 ccgate 的进程组中，只转发 groupSignals 以外的信号，
+// ccgate 仍捕获这些信号，使其在子进程退出后记录状态。
+// watchdog 不为 nil 时到达 max_session 会中断子进程；此时 ccgate 看不到终端的输入输出，不检查空闲时间。
+func superviseProcess(path string, argv []string, env []string, watchdog *sessionWatchdog) (supervisedExit, error) {
+	cmd := exec.Command(path)
+	cmd.Args = argv
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	tty := int(os.Stdin.Fd())
+	foreground := term.IsTerminal(tty)
+	if foreground {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: true, Ctty: tty}
+	}
+
+	sigs := make(chan os.Signal, 8)
+	signal.Notify(sigs, forwardedSignals...)
+	defer signal.Reset(forwardedSignals...)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return supervisedExit{}, err
+	}
+	pid := cmd.Process.Pid
+
+	// 独立进程组时向整个组转发，使 claude 启动的工具进程也能收到
+	target := pid
+	if foreground {
+		target = -pid
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				if !foreground && groupSignals[sig] {
+					continue
+				}
+				_ = syscall.Kill(target, sig.(syscall.Signal))
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer close(done)
+	defer watchdog.watch(func(sig syscall.Signal) { _ = syscall.Kill(target, sig) })()
+
+	ownPgrp := syscall.Getpgrp()
+	ws, err := waitProcess(pid, func() {
+		if foreground {
+			setForegroundPgrp(tty, ownPgrp)
+		}
+		suspendSelf()
+		if foreground {
+			setForegroundPgrp(tty, pid)
+		}
+		_ = syscall.Kill(target, syscall.SIGCONT)
+	})
+	if foreground {
+		setForegroundPgrp(tty, ownPgrp)
+	}
+	if err != nil {
+		return supervisedExit{}, err
+	}
+	return exitStatus(ws, start), nil
+}
+
+// waitProcess 等待子进程退出；子进程被挂起（如 Ctrl+Z）时调用 suspend，之后继续等待
+func waitProcess(pid int, suspend func()) (syscall.WaitStatus, error) {
+	for {
+		var ws syscall.WaitStatus
+		_, err := syscall.Wait4(pid, &ws, syscall.WUNTRACED, nil)
+		if errors.Is(err, syscall.EINTR) {
+			continue
+		}
+		if err != nil {
+			return ws, fmt.Errorf("等待子进程失败: %w", err)
+		}
+		if ws.Stopped() {
+			suspend()
+			continue
+		}
+		return ws, nil
+	}
+}
+
+// suspendSelf 挂起 ccgate 自身，交由上层 shell 进行作业控制；fg 后返回
+func suspendSelf() {
+	_ = syscall.Kill(os.Getpid(), syscall.SIGSTOP)
+}
+
+// exitStatus 根据 wait 状态生成子进程的退出信息
+func exitStatus(ws syscall.WaitStatus, start time.Time) supervisedExit {
+	status := supervisedExit{Code: ws.ExitStatus(), Duration: time.Since(start)}
+	if ws.Signaled() {
+		status.Signal = ws.Signal()
+	}
+	return status
+}
+
+// setForegroundPgrp 将终端的前台进程组设为 pgrp
+// 后台进程调用 tcsetpgrp 会收到 SIGTTOU，因此调用期间忽略该信号
+func setForegroundPgrp(tty, pgrp int) {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	_ = unix.IoctlSetPointerInt(tty, unix.TIOCSPGRP, pgrp)
+}
+
+// exitWithStatus 以与子进程相同的状态退出：被信号终止时向自身重新发送该信号
+func exitWithStatus(status supervisedExit) {
+	if status.Signal != 0 {
+		signal.Reset(status.Signal)
+		_ = syscall.Kill(os.Getpid(), status.Signal)
+		// 信号被阻塞或忽略时退回到 128+信号值
+		time.Sleep(100 * time.Millisecond)
+	}
+	os.Exit(status.ExitCode())
+}
+
+// runSupervised 托管运行 claude，退出后执行启动后的工作并以 claude 的状态退出
+func runSupervised(req *launchRequest, path string, argv, env []string, entry HistoryEntry) error {
+	// 空闲检测需要由 ccgate 经 pty 转发终端的输入输出；不经过 pty 时只限制会话时长
+	limits := req.Limits
+	usePty := req.Record || (limits.IdleTimeout > 0 && term.IsTerminal(int(os.Stdin.Fd())))
+	if !usePty {
+		limits.IdleTimeout = 0
+	}
+	watchdog := newSessionWatchdog(limits)
+
+	var status supervisedExit
+	var err error
+	switch {
+	case req.Record:
+		status, err = runRecorded(req, path, argv, env, &entry, watchdog)
+	case usePty:
+		status, err = ptyProcess(path, argv, env, nil, watchdog)
+	default:
+		status, err = superviseProcess(path, argv, env, watchdog)
+	}
+	if err != nil {
+		return fmt.Errorf("启动 claude 失败: %w", err)
+	}
+	if reason := watchdog.Reason(); reason != "" {
+		entry.EndReason = reason
+		fmt.Fprintf(os.Stderr, "→ 会话因 %s 限制结束（%s）\n", reason, req.Limits)
+	}
+
+	// 启动后的工作：记录退出码、时长和终止信号
+	code := status.ExitCode()
+	entry.ExitCode = &code
+	entry.DurationMS = status.Duration.Milliseconds()
+	if status.Signal != 0 {
+		entry.Signal = unix.SignalName(status.Signal)
+	}
+	recordLaunch(entry)
+
+	// 启动后钩子失败不影响 claude 的退出状态
+	if len(req.Hooks.PostLaunch) > 0 {
+		hookEnv := append(env, hookContextEnv(hookPostLaunch, req.Platform, req.Args)...)
+		hookEnv = append(hookEnv,
+			fmt.Sprintf("CCGATE_EXIT_CODE=%d", code),
+			fmt.Sprintf("CCGATE_DURATION_MS=%d", entry.DurationMS))
+		if err := runHooks(hookPostLaunch, req.Hooks.PostLaunch, hookEnv, req.Hooks.Timeout); err != nil {
+			DisplayWarning(err.Error(), DefaultTheme())
+		}
+	}
+
+	if code != 0 {
+		exitWithStatus(status)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -132,6 +133,11 @@ const (
 	SeverityCritical                // 严重
 )
 
+// Error 实现 error 接口，使 UIError 可以直接作为错误返回
+func (e *UIError) Error() string {
+	return e.Message
+}
+
 // DisplayError 显示错误信息
 func (e *UIError) DisplayError(theme *Theme) {
 	layout := GetResponsiveLayout()
@@ -151,20 +157,26 @@ func (e *UIError) DisplayError(theme *Theme) {
 		color = theme.Colors.Muted
 	}
 
+	// --output json|yaml 时标准输出只包含结构化数据，错误写到标准错误
+	w := io.Writer(os.Stdout)
+	if structuredOutput() {
+		w = os.Stderr
+	}
+
 	// 显示错误标题
 	title := pterm.Sprintf("[%s] %s", color.Sprint("错误"), e.Message)
-	fmt.Println(title)
+	fmt.Fprintln(w, title)
 
 	// 显示恢复建议（如果有）
 	if e.Recovery != "" {
-		pterm.Printf("%s %s\n", theme.Colors.Secondary.Sprint("建议:"), e.Recovery)
+		fmt.Fprintf(w, "%s %s\n", theme.Colors.Secondary.Sprint("建议:"), e.Recovery)
 	}
 
 	// 添加间距
 	if layout.CompactMode {
-		fmt.Println()
+		fmt.Fprintln(w)
 	} else {
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 }
 
@@ -190,6 +202,17 @@ func NewConfigError(message string, recovery string) *UIError {
 	}
 }
 
+// NewNetworkError 创建网络错误
+func NewNetworkError(message string, recovery string) *UIError {
+	return &UIError{
+		Type:      ErrorTypeNetwork,
+		Message:   message,
+		Recovery:  recovery,
+		Severity:  SeverityError,
+		Timestamp: time.Now(),
+	}
+}
+
 // NewUserError 创建用户操作错误
 func NewUserError(message string, recovery string) *UIError {
 	return &UIError{
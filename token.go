@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// tokenCommandTimeout 令牌命令的超时时间
+const tokenCommandTimeout = 30 * time.Second
+
+// tokenCacheEntry 单个平台缓存的令牌（AES-256-GCM 加密）
+type tokenCacheEntry struct {
+	// CommandHash 生成令牌的命令摘要，命令变化后缓存失效
+	CommandHash string    `json:"command_hash"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Nonce       []byte    `json:"nonce"`
+	Ciphertext  []byte    `json:"ciphertext"`
+}
+
+// token 子命令组
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "管理 token_command 生成的短期令牌缓存",
+	Long: `平台配置了 token_command 时，ccgate 会在启动前运行该命令获取令牌，
+并按 token_ttl 加密缓存在 ~/.ccgate/token-cache.json（权限 0600）。
+
+示例:
+  ccgate token refresh gateway
+  ccgate token clear`,
+	Args: cobra.NoArgs,
+}
+
+// token refresh 子命令
+var tokenRefreshCmd = &cobra.Command{
+	Use:   "refresh <name>",
+	Short: "忽略缓存，重新运行 token_command 获取令牌",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+		platform, err := findPlatformByName(config.Platforms, args[0])
+		if err != nil {
+			return unknownPlatformError(config.Platforms, args[0], err)
+		}
+		if platform.TokenCommand == "" {
+			return NewUserError(
+				fmt.Sprintf("平台 '%s' 没有配置 token_command", platform.Name),
+				"在配置中为该平台添加 token_command 和 token_ttl")
+		}
+
+		token, uiErr := refreshPlatformToken(platform)
+		if uiErr != nil {
+			return uiErr
+		}
+
+		theme := DefaultTheme()
+		DisplaySuccess(fmt.Sprintf("已获取平台 '%s' 的令牌: %s", platform.Name, maskToken(token)), theme)
+		if ttl := platform.tokenTTL(); ttl > 0 {
+			DisplayInfo(fmt.Sprintf("缓存有效期至 %s", time.Now().Add(ttl).Format("2006-01-02 15:04:05")), theme)
+		}
+		return nil
+	},
+}
+
+// token clear 子命令
+var tokenClearCmd = &cobra.Command{
+	Use:   "clear [name]",
+	Short: "清除缓存的令牌（省略名称时清除全部）",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		theme := DefaultTheme()
+		if len(args) == 0 {
+			if err := os.Remove(tokenCachePath()); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("清除令牌缓存失败: %w", err)
+			}
+			DisplaySuccess("已清除全部缓存的令牌", theme)
+			return nil
+		}
+
+		cache, err := loadTokenCache()
+		if err != nil {
+			return err
+		}
+		if _, ok := cache[args[0]]; !ok {
+			DisplayWarning(fmt.Sprintf("平台 '%s' 没有缓存的令牌", args[0]), theme)
+			return nil
+		}
+		delete(cache, args[0])
+		if err := saveTokenCache(cache); err != nil {
+			return err
+		}
+		DisplaySuccess(fmt.Sprintf("已清除平台 '%s' 缓存的令牌", args[0]), theme)
+		return nil
+	},
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenRefreshCmd)
+	tokenCmd.AddCommand(tokenClearCmd)
+}
+
+// tokenTTL 返回令牌缓存时长，未配置或无效时为 0（不缓存）
+func (p *Platform) tokenTTL() time.Duration {
+	d, err := time.ParseDuration(p.TokenTTL)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// tokenCachePath 返回令牌缓存文件路径
+func tokenCachePath() string {
+	return filepath.Join(filepath.Dir(getConfigPath()), "token-cache.json")
+}
+
+// tokenKeyPath 返回令牌缓存密钥文件路径
+func tokenKeyPath() string {
+	return filepath.Join(filepath.Dir(getConfigPath()), "token-cache.key")
+}
+
+// ensurePlatformToken 为配置了 token_command 的平台填充令牌（仅在内存中，不写回配置）
+// 优先使用未过期的缓存，否则运行命令并按 token_ttl 缓存
+func ensurePlatformToken(platform *Platform) *UIError {
+	if platform.TokenCommand == "" {
+		return nil
+	}
+	if token, ok := cachedToken(platform); ok {
+		platform.AnthropicAuthToken = token
+		return nil
+	}
+	_, uiErr := refreshPlatformToken(platform)
+	return uiErr
+}
+
+// refreshPlatformToken 运行 token_command 获取令牌，写入缓存并填充到平台
+func refreshPlatformToken(platform *Platform) (string, *UIError) {
+	token, err := runTokenCommand(platform.TokenCommand)
+	if err != nil {
+		return "", NewConfigError(
+			fmt.Sprintf("平台 '%s' 的 token_command 执行失败: %v", platform.Name, err),
+			fmt.Sprintf("先手动运行该命令确认可以输出令牌，修复后使用 'ccgate token refresh %s' 重试", platform.Name))
+	}
+	platform.AnthropicAuthToken = token
+
+	if ttl := platform.tokenTTL(); ttl > 0 {
+		if err := storeToken(platform, token, time.Now().Add(ttl)); err != nil {
+			DisplayWarning(fmt.Sprintf("缓存令牌失败: %v", err), DefaultTheme())
+		}
+	}
+	return token, nil
+}
+
+// runTokenCommand 通过 sh -c 运行令牌命令，返回去除首尾空白的标准输出
+func runTokenCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("超过 %s 未完成", tokenCommandTimeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", fmt.Errorf("退出码 %d", exitErr.ExitCode())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("命令没有输出令牌")
+	}
+	return token, nil
+}
+
+// commandHash 返回令牌命令的摘要
+func commandHash(command string) string {
+	sum := sha256.Sum256([]byte(command))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedToken 返回平台未过期的缓存令牌
+func cachedToken(platform *Platform) (string, bool) {
+	cache, err := loadTokenCache()
+	if err != nil {
+		return "", false
+	}
+	entry, ok := cache[platform.Name]
+	if !ok || entry.CommandHash != commandHash(platform.TokenCommand) || !time.Now().Before(entry.ExpiresAt) {
+		return "", false
+	}
+	gcm, err := tokenCipher(false)
+	if err != nil {
+		return "", false
+	}
+	plain, err := gcm.Open(nil, entry.Nonce, entry.Ciphertext, []byte(platform.Name))
+	if err != nil {
+		return "", false
+	}
+	return string(plain), true
+}
+
+// storeToken 加密并缓存平台令牌
+func storeToken(platform *Platform, token string, expiresAt time.Time) error {
+	cache, err := loadTokenCache()
+	if err != nil {
+		cache = make(map[string]*tokenCacheEntry)
+	}
+	gcm, err := tokenCipher(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	cache[platform.Name] = &tokenCacheEntry{
+		CommandHash: commandHash(platform.TokenCommand),
+		ExpiresAt:   expiresAt,
+		Nonce:       nonce,
+		// 以平台名称作为附加数据，防止缓存条目被替换到其他平台
+		Ciphertext: gcm.Seal(nil, nonce, []byte(token), []byte(platform.Name)),
+	}
+	return saveTokenCache(cache)
+}
+
+// tokenCipher 使用本机密钥文件创建加密器，create 为 true 时在密钥不存在时生成
+func tokenCipher(create bool) (cipher.AEAD, error) {
+	path := tokenKeyPath()
+	key, err := os.ReadFile(path)
+	if os.IsNotExist(err) && create {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, key, 0o600); err != nil {
+			return nil, fmt.Errorf("写入令牌缓存密钥失败: %w", err)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("令牌缓存密钥 %s 无效", path)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// loadTokenCache 读取令牌缓存，文件不存在时返回空缓存
+func loadTokenCache() (map[string]*tokenCacheEntry, error) {
+	cache := make(map[string]*tokenCacheEntry)
+	data, err := os.ReadFile(tokenCachePath())
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取令牌缓存失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("令牌缓存格式无效: %w", err)
+	}
+	return cache, nil
+}
+
+// saveTokenCache 保存令牌缓存（权限 0600）
+func saveTokenCache(cache map[string]*tokenCacheEntry) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := tokenCachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("写入令牌缓存失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEnsurePlatformToken tests running token_command and caching the result
+func TestEnsurePlatformToken(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	counter := filepath.Join(home, "count")
+	command := "echo x >> " + counter + "; echo '  tok-123  '"
+	platform := &Platform{Name: "gateway", TokenCommand: command, TokenTTL: "1h"}
+
+	if uiErr := ensurePlatformToken(platform); uiErr != nil {
+		t.Fatalf("Expected no error, got %v", uiErr)
+	}
+	if platform.AnthropicAuthToken != "tok-123" {
+		t.Errorf("Expected trimmed token, got %q", platform.AnthropicAuthToken)
+	}
+
+	// 第二次应命中缓存，不再运行命令
+	again := &Platform{Name: "gateway", TokenCommand: command, TokenTTL: "1h"}
+	if uiErr := ensurePlatformToken(again); uiErr != nil || again.AnthropicAuthToken != "tok-123" {
+		t.Fatalf("Expected cached token, got %q (%v)", again.AnthropicAuthToken, uiErr)
+	}
+	data, _ := os.ReadFile(counter)
+	if n := strings.Count(string(data), "x"); n != 1 {
+		t.Errorf("Expected command to run once, ran %d times", n)
+	}
+
+	info, err := os.Stat(tokenCachePath())
+	if err != nil {
+		t.Fatalf("Expected cache file, got %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected cache mode 0600, got %v", info.Mode().Perm())
+	}
+	raw, _ := os.ReadFile(tokenCachePath())
+	if strings.Contains(string(raw), "tok-123") {
+		t.Error("Expected cached token to be encrypted")
+	}
+
+	// 命令变化后缓存失效
+	changed := &Platform{Name: "gateway", TokenCommand: "echo other", TokenTTL: "1h"}
+	if uiErr := ensurePlatformToken(changed); uiErr != nil || changed.AnthropicAuthToken != "other" {
+		t.Errorf("Expected cache miss after command change, got %q (%v)", changed.AnthropicAuthToken, uiErr)
+	}
+}
+
+// TestTokenCacheExpiry tests that expired entries are ignored
+func TestTokenCacheExpiry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	platform := &Platform{Name: "gateway", TokenCommand: "echo fresh"}
+	if err := storeToken(platform, "stale", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := cachedToken(platform); ok {
+		t.Error("Expected expired token to be ignored")
+	}
+
+	if err := storeToken(platform, "valid", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token, ok := cachedToken(platform); !ok || token != "valid" {
+		t.Errorf("Expected valid cached token, got %q", token)
+	}
+	// 缓存条目不能被其他平台使用
+	if _, ok := cachedToken(&Platform{Name: "other", TokenCommand: "echo fresh"}); ok {
+		t.Error("Expected cache entry to be bound to its platform")
+	}
+}
+
+// TestTokenCommandFailure tests UIError for failing or empty helpers
+func TestTokenCommandFailure(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"exit 2", "退出码 2"},
+		{"true", "没有输出令牌"},
+	}
+	for _, tt := range tests {
+		platform := &Platform{Name: "gateway", TokenCommand: tt.command, TokenTTL: "1h"}
+		uiErr := ensurePlatformToken(platform)
+		if uiErr == nil || uiErr.Type != ErrorTypeConfig || !strings.Contains(uiErr.Message, tt.want) {
+			t.Errorf("%q: expected config UIError containing %q, got %v", tt.command, tt.want, uiErr)
+			continue
+		}
+		if !strings.Contains(uiErr.Recovery, "ccgate token refresh gateway") {
+			t.Errorf("%q: expected refresh hint, got %q", tt.command, uiErr.Recovery)
+		}
+	}
+}
+
+// TestPlatformValidateTokenCommand tests token_command and token_ttl validation
+func TestPlatformValidateTokenCommand(t *testing.T) {
+	platform := Platform{Name: "gw", AnthropicBaseURL: "https://gw", AnthropicModel: "m", TokenCommand: "get-token"}
+	if err := platform.Validate(); err != nil {
+		t.Errorf("Expected token_command to replace static token, got %v", err)
+	}
+	platform.TokenTTL = "soon"
+	if err := platform.Validate(); err == nil {
+		t.Error("Expected error for invalid token_ttl, got nil")
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	useLocal bool
+	useUnset bool
+)
+
+// use 子命令
+var useCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "设置默认平台（全局或当前目录）",
+	Long: `设置直接运行 ccgate 时使用的默认平台。
+
+默认写入全局配置；使用 --local 时仅绑定当前目录（及其子目录）。
+使用 --unset 清除对应的设置。
+
+示例:
+  ccgate use prod            # 全局默认使用 prod
+  ccgate use staging --local # 当前目录使用 staging
+  ccgate use --unset --local # 清除当前目录的绑定`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if useUnset {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("获取当前目录失败: %w", err)
+		}
+		cwd = filepath.Clean(cwd)
+
+		theme := DefaultTheme()
+
+		if useUnset {
+			if useLocal {
+				if _, ok := config.Directories[cwd]; !ok {
+					DisplayWarning(fmt.Sprintf("目录 %s 没有绑定平台", cwd), theme)
+					return nil
+				}
+				delete(config.Directories, cwd)
+			} else {
+				config.Default = ""
+			}
+			if err := saveConfig(config, cfgFile); err != nil {
+				return err
+			}
+			DisplaySuccess("已清除默认平台设置", theme)
+			return nil
+		}
+
+		name := args[0]
+		if _, err := findPlatformByName(config.Platforms, name); err != nil {
+			return unknownPlatformError(config.Platforms, name, err)
+		}
+
+		if useLocal {
+			if config.Directories == nil {
+				config.Directories = make(map[string]string)
+			}
+			config.Directories[cwd] = name
+		} else {
+			config.Default = name
+		}
+
+		if err := saveConfig(config, cfgFile); err != nil {
+			return err
+		}
+
+		if useLocal {
+			DisplaySuccess(fmt.Sprintf("目录 %s 已绑定平台 '%s'", cwd, name), theme)
+		} else {
+			DisplaySuccess(fmt.Sprintf("默认平台已设置为 '%s'", name), theme)
+		}
+		return nil
+	},
+}
+
+// current 子命令
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "显示当前会使用的平台及选择依据",
+	Long: `显示在当前目录直接运行 ccgate 时会选择的平台，以及完整的解析过程。
+
+解析顺序: -p/--platform > CCGATE_PLATFORM 环境变量 > 目录绑定 > 规则 > 默认平台 > 唯一平台 > 交互式选择`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
+
+		theme := DefaultTheme()
+		if len(config.Platforms) == 0 {
+			DisplayWarning("没有配置任何平台", theme)
+			fmt.Println("使用 'ccgate add' 命令添加新平台")
+			return nil
+		}
+
+		cwd, _ := os.Getwd()
+		res, err := resolvePlatform(config, platformName, cwd)
+		if err != nil {
+			return err
+		}
+
+		printResolution(res, theme)
+		return nil
+	},
+}
+
+func init() {
+	useCmd.Flags().BoolVar(&useLocal, "local", false, "仅绑定当前目录")
+	useCmd.Flags().BoolVar(&useUnset, "unset", false, "清除默认平台或目录绑定")
+	currentCmd.Flags().StringVarP(&platformName, "platform", "p", "", "模拟指定平台名称")
+}
+
+// printResolution 打印平台解析结果和过程
+func printResolution(res *Resolution, theme *Theme) {
+	if res.Platform != nil {
+		pterm.Info.Printf("%s %s %s\n",
+			theme.Colors.Primary.Sprint("当前平台:"),
+			theme.Colors.Success.Sprint(res.Platform.Name),
+			theme.Colors.Muted.Sprintf("（来源: %s）", sourceLabel(res.Source)))
+	} else if term.IsTerminal(int(os.Stdin.Fd())) {
+		pterm.Info.Printf("%s\n", theme.Colors.Warning.Sprint("未能自动确定平台，运行时将进行交互式选择"))
+	} else {
+		pterm.Info.Printf("%s\n", theme.Colors.Warning.Sprint("未能自动确定平台，且当前环境不支持交互式选择，请使用 -p 指定"))
+	}
+
+	Spacer(theme.Spacing.XS, theme)
+	pterm.Printf("%s\n", theme.Colors.Secondary.Sprint("解析过程:"))
+	for i, step := range res.Steps {
+		mark := theme.Colors.Muted.Sprint("-")
+		if step.Matched {
+			mark = theme.Colors.Success.Sprint("✓")
+		}
+		pterm.Printf("  %d. %s %s: %s\n", i+1, mark, sourceLabel(step.Source), step.Detail)
+	}
+	if res.Platform == nil {
+		pterm.Printf("  %d. %s %s: %s\n", len(res.Steps)+1,
+			theme.Colors.Warning.Sprint("→"), sourceLabel(SourceInteractive), "运行时选择")
+	}
+}